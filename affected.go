@@ -6,10 +6,11 @@ import (
 
 // Affected to record lastId for insert, and affected rows for update, inserts, delete statement
 type Affected struct {
-	result       sql.Result
-	lastId       int64
-	rowsAffected int64
-	driver       dbDriver
+	result           sql.Result
+	lastId           int64
+	rowsAffected     int64
+	rowsAffectedList []int64 // rows affected of each statement, for UpdateMany
+	driver           dbDriver
 }
 
 // GetLastId get lasted modified row id
@@ -17,6 +18,14 @@ func (a *Affected) GetLastId() (int64, error) {
 	if a.lastId != 0 {
 		return a.lastId, nil
 	}
+	// clickhouse has no auto increment id
+	if a.driver == driverClickhouse {
+		return 0, ErrNotSupportForThisDriver
+	}
+	// the id returned by RETURNING clause is zero
+	if a.result == nil {
+		return a.lastId, nil
+	}
 	if a.driver == driverPostgresql {
 		return 0, ErrNotSupportForThisDriver
 	}
@@ -33,10 +42,19 @@ func (a *Affected) GetRowsAffected() (int64, error) {
 	if a.rowsAffected == -1 {
 		return 0, ErrNotSupportForThisDriver
 	}
-	if a.rowsAffected != 0 {
+	if a.rowsAffected != 0 || a.result == nil {
 		return a.rowsAffected, nil
 	}
 	var err error
 	a.rowsAffected, err = a.result.RowsAffected()
 	return a.rowsAffected, err
 }
+
+// GetRowsAffectedList returns the number of rows affected by each statement,
+// only for UpdateMany, which executes statement with each row of arguments
+func (a *Affected) GetRowsAffectedList() ([]int64, error) {
+	if a.rowsAffectedList == nil {
+		return nil, ErrNotSupportForThisDriver
+	}
+	return a.rowsAffectedList, nil
+}
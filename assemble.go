@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -85,14 +86,38 @@ func fieldsIterate(kvMap map[string][]int, pos []int, field reflect.StructField)
 			fieldsIterate(kvMap, _pos, fieldType.Field(i))
 		}
 	} else {
-		tag := field.Tag.Get("sql")
-		if tag == "" {
-			tag = field.Name
-		}
+		tag, _ := sqlTag(field)
 		kvMap[tag] = pos
 	}
 }
 
+// sqlTag column name and options of `sql` tag, such as `sql:"id,pk"`,
+// the name of field is used if column name is omitted
+func sqlTag(field reflect.StructField) (string, []string) {
+	parts := strings.Split(field.Tag.Get("sql"), ",")
+	name := strings.TrimSpace(parts[0])
+	if name == "" {
+		name = field.Name
+	}
+	var opts []string
+	for _, o := range parts[1:] {
+		if o = strings.TrimSpace(o); o != "" {
+			opts = append(opts, o)
+		}
+	}
+	return name, opts
+}
+
+// hasTagOption option is set in `sql` tag
+func hasTagOption(opts []string, opt string) bool {
+	for _, o := range opts {
+		if strings.EqualFold(o, opt) {
+			return true
+		}
+	}
+	return false
+}
+
 // fieldsMap
 func fieldsColsMap(cols []string, mType reflect.Type) ([][]int, error) {
 	kvMap := make(map[string][]int)
@@ -387,3 +412,18 @@ func checkOneV2(rows *sql.Rows, dest interface{}) error {
 	}
 	return ErrContainer
 }
+
+// scan all rows into slice, or only one row into struct, map or base type
+func checkDest(rows *sql.Rows, dest interface{}) error {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		_ = closeRows(rows)
+		return ErrContainer
+	}
+	// slice but not []byte or sql.Scanner such as StringArray
+	t := directType(val.Type())
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 && !reflect.PtrTo(t).Implements(_scanner) {
+		return checkAllV2(rows, dest)
+	}
+	return checkOneV2(rows, dest)
+}
@@ -0,0 +1,547 @@
+package sqly
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Cond condition of where clause, composed by And, Or
+type Cond struct {
+	expr  string
+	args  []interface{}
+	op    string // AND, OR to join conds
+	conds []Cond
+}
+
+// Expr raw condition with `?` placeholders, such as Expr("age > ?", 18)
+func Expr(expr string, args ...interface{}) Cond {
+	return Cond{expr: expr, args: args}
+}
+
+// And join conditions with AND
+func And(conds ...Cond) Cond {
+	return Cond{op: "AND", conds: conds}
+}
+
+// Or join conditions with OR
+func Or(conds ...Cond) Cond {
+	return Cond{op: "OR", conds: conds}
+}
+
+// In col IN (arr...), arr is a slice
+func In(col string, arr interface{}) Cond {
+	return Cond{expr: col + " IN ?", args: []interface{}{arr}}
+}
+
+// NotIn col NOT IN (arr...), arr is a slice
+func NotIn(col string, arr interface{}) Cond {
+	return Cond{expr: col + " NOT IN ?", args: []interface{}{arr}}
+}
+
+// Between col BETWEEN from AND to
+func Between(col string, from, to interface{}) Cond {
+	return Cond{expr: col + " BETWEEN ? AND ?", args: []interface{}{from, to}}
+}
+
+// IsNull col IS NULL
+func IsNull(col string) Cond {
+	return Cond{expr: col + " IS NULL"}
+}
+
+// IsNotNull col IS NOT NULL
+func IsNotNull(col string) Cond {
+	return Cond{expr: col + " IS NOT NULL"}
+}
+
+// empty array argument, the condition is skipped
+func isEmptyArg(arg interface{}) bool {
+	v := reflect.ValueOf(arg)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	return v.Len() == 0
+}
+
+// condition has empty array argument, including its composed conditions
+func (c Cond) hasEmptyArg() bool {
+	for _, arg := range c.args {
+		if isEmptyArg(arg) {
+			return true
+		}
+	}
+	for _, sub := range c.conds {
+		if sub.hasEmptyArg() {
+			return true
+		}
+	}
+	return false
+}
+
+// render condition, conditions with empty array argument are skipped,
+// composed conditions are enclosed in parentheses unless it's top level
+func (c Cond) render(top bool) (string, []interface{}) {
+	if c.op == "" {
+		if c.hasEmptyArg() {
+			return "", nil
+		}
+		return c.expr, c.args
+	}
+	var exprs []string
+	var args []interface{}
+	for _, sub := range c.conds {
+		e, a := sub.render(false)
+		if e == "" {
+			continue
+		}
+		exprs = append(exprs, e)
+		args = append(args, a...)
+	}
+	if len(exprs) == 0 {
+		return "", nil
+	}
+	if len(exprs) == 1 {
+		return exprs[0], args
+	}
+	if top {
+		return strings.Join(exprs, " "+c.op+" "), args
+	}
+	return "(" + strings.Join(exprs, " "+c.op+" ") + ")", args
+}
+
+// condition of where, cond is a Cond or raw condition string with args
+func toCond(cond interface{}, args []interface{}) (Cond, error) {
+	switch c := cond.(type) {
+	case Cond:
+		return c, nil
+	case string:
+		return Expr(c, args...), nil
+	}
+	return Cond{}, ErrArgType
+}
+
+// where clause of update and delete, conditions with empty array argument are not skipped,
+// as it would modify more rows than expected
+func writeWhereFmt(conds []Cond, all bool) (string, []interface{}, error) {
+	for _, c := range conds {
+		if c.hasEmptyArg() {
+			return "", nil, ErrEmptyArrayInStatement
+		}
+	}
+	where, args := whereFmt(conds)
+	if where == "" && !all {
+		return "", nil, ErrNoWhere
+	}
+	return where, args, nil
+}
+
+// where clause, conditions are joined with AND
+func whereFmt(conds []Cond) (string, []interface{}) {
+	where, args := And(conds...).render(true)
+	if where == "" {
+		return "", nil
+	}
+	return " WHERE " + where, args
+}
+
+type queryFunc func(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+
+// SelectBuilder build select statement, and query it to dest
+type SelectBuilder struct {
+	driver  dbDriver
+	argFmt  argFormat
+	query   queryFunc
+	get     queryFunc
+	cols    []string
+	from    string
+	joins   []string
+	where   []Cond
+	groupBy []string
+	having  []Cond
+	orderBy []string
+	limit   int64
+	offset  int64
+	err     error
+}
+
+func newSelect(driver dbDriver, argFmt argFormat, query, get queryFunc, cols []string) *SelectBuilder {
+	return &SelectBuilder{driver: driver, argFmt: argFmt, query: query, get: get, cols: cols, limit: -1}
+}
+
+// Select build select statement, all columns (*) are selected if cols is empty
+func (s *SqlY) Select(cols ...string) *SelectBuilder {
+	return newSelect(s.driver, s.argFmt, s.QueryCtx, s.GetCtx, cols)
+}
+
+// From table
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = table
+	return b
+}
+
+// Join join clause, such as Join("LEFT JOIN `role` ON `role`.`id`=`account`.`role`")
+func (b *SelectBuilder) Join(join string) *SelectBuilder {
+	b.joins = append(b.joins, join)
+	return b
+}
+
+// Where add condition, cond is a Cond or raw condition string with args,
+// conditions are joined with AND, and skipped if has empty array argument
+func (b *SelectBuilder) Where(cond interface{}, args ...interface{}) *SelectBuilder {
+	c, err := toCond(cond, args)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.where = append(b.where, c)
+	return b
+}
+
+// GroupBy group by columns
+func (b *SelectBuilder) GroupBy(cols ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, cols...)
+	return b
+}
+
+// Having add condition of group by, as Where does
+func (b *SelectBuilder) Having(cond interface{}, args ...interface{}) *SelectBuilder {
+	c, err := toCond(cond, args)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.having = append(b.having, c)
+	return b
+}
+
+// OrderBy order by columns, such as OrderBy("id DESC")
+func (b *SelectBuilder) OrderBy(cols ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, cols...)
+	return b
+}
+
+// Limit limit number of rows
+func (b *SelectBuilder) Limit(limit int64) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Offset skip number of rows
+func (b *SelectBuilder) Offset(offset int64) *SelectBuilder {
+	b.offset = offset
+	return b
+}
+
+// ToSQL statement with `?` placeholders and its arguments
+func (b *SelectBuilder) ToSQL() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if b.from == "" {
+		return "", nil, ErrStatement
+	}
+	cols := "*"
+	if len(b.cols) > 0 {
+		cols = strings.Join(b.cols, ", ")
+	}
+	query := "SELECT " + cols + " FROM " + b.from
+	for _, j := range b.joins {
+		query += " " + j
+	}
+	where, args := whereFmt(b.where)
+	query += where
+	if len(b.groupBy) > 0 {
+		query += " GROUP BY " + strings.Join(b.groupBy, ", ")
+		if having, hArgs := And(b.having...).render(true); having != "" {
+			query += " HAVING " + having
+			args = append(args, hArgs...)
+		}
+	}
+	if len(b.orderBy) > 0 {
+		query += " ORDER BY " + strings.Join(b.orderBy, ", ")
+	}
+	if b.limit >= 0 {
+		query = limitFmt(b.driver, query, b.limit, b.offset)
+	} else if b.offset > 0 {
+		query = limitFmt(b.driver, query, math.MaxInt64, b.offset)
+	}
+	return query, args, nil
+}
+
+// Format statement with arguments formatted by dialect
+func (b *SelectBuilder) Format() (string, error) {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return "", err
+	}
+	return statementFormat(query, b.argFmt, args...)
+}
+
+// Query query rows to dest, as SqlY.Query does
+func (b *SelectBuilder) Query(ctx context.Context, dest interface{}) error {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return err
+	}
+	return b.query(ctx, dest, query, args...)
+}
+
+// Get query one row to dest, as SqlY.Get does
+func (b *SelectBuilder) Get(ctx context.Context, dest interface{}) error {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return err
+	}
+	return b.get(ctx, dest, query, args...)
+}
+
+type execFunc func(ctx context.Context, query string, args ...interface{}) (*Affected, error)
+
+type execManyFunc func(ctx context.Context, query string, args [][]interface{}) (*Affected, error)
+
+// InsertBuilder build insert statement of rows
+type InsertBuilder struct {
+	driver   dbDriver
+	argFmt   argFormat
+	execMany execManyFunc
+	table    string
+	cols     []string
+	rows     [][]interface{}
+	err      error
+}
+
+// InsertInto build insert statement of table
+func (s *SqlY) InsertInto(table string) *InsertBuilder {
+	return &InsertBuilder{driver: s.driver, argFmt: s.argFmt, execMany: s.InsertManyCtx, table: table}
+}
+
+// Columns columns to insert, Rows picks these columns from structs or maps
+func (b *InsertBuilder) Columns(cols ...string) *InsertBuilder {
+	b.cols = cols
+	return b
+}
+
+// Values add a row, values are in order of Columns
+func (b *InsertBuilder) Values(vals ...interface{}) *InsertBuilder {
+	b.rows = append(b.rows, vals)
+	return b
+}
+
+// Rows add rows from struct, struct pointer, map[string]interface{}, or slice of them,
+// columns are taken from `sql` tags or map keys if Columns is not set
+func (b *InsertBuilder) Rows(rows interface{}) *InsertBuilder {
+	var cols []string
+	var vals [][]interface{}
+	var err error
+	if v := reflect.Indirect(reflect.ValueOf(rows)); v.Kind() == reflect.Slice {
+		cols, vals, err = rowsData(rows)
+	} else {
+		var val []interface{}
+		cols, val, err = rowData(rows)
+		vals = [][]interface{}{val}
+	}
+	if err != nil {
+		b.err = err
+		return b
+	}
+	if b.cols == nil {
+		b.cols = cols
+	}
+	if vals, err = pickColumns(cols, vals, b.cols); err != nil {
+		b.err = err
+		return b
+	}
+	b.rows = append(b.rows, vals...)
+	return b
+}
+
+// ToSQL statement with `?` placeholders of one row, and arguments of each row
+func (b *InsertBuilder) ToSQL() (string, [][]interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if b.table == "" || len(b.cols) == 0 || len(b.rows) == 0 {
+		return "", nil, ErrStatement
+	}
+	for _, row := range b.rows {
+		if len(row) != len(b.cols) {
+			return "", nil, ErrFieldsMatch
+		}
+	}
+	return insertFmt(b.driver, b.table, b.cols), b.rows, nil
+}
+
+// Format statement with arguments formatted by dialect
+func (b *InsertBuilder) Format() (string, error) {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return "", err
+	}
+	return multiRowsFmt(b.driver, query, b.argFmt, args)
+}
+
+// Exec insert rows, as SqlY.InsertMany does
+func (b *InsertBuilder) Exec(ctx context.Context) (*Affected, error) {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	return b.execMany(ctx, query, args)
+}
+
+// UpdateBuilder build update statement, Where or All is required
+type UpdateBuilder struct {
+	driver dbDriver
+	argFmt argFormat
+	exec   execFunc
+	table  string
+	cols   []string
+	vals   []interface{}
+	where  []Cond
+	all    bool
+	err    error
+}
+
+// UpdateTable build update statement of table
+func (s *SqlY) UpdateTable(table string) *UpdateBuilder {
+	return &UpdateBuilder{driver: s.driver, argFmt: s.argFmt, exec: s.ExecCtx, table: table}
+}
+
+// Set columns to update, values is map[string]interface{}, struct or struct pointer
+func (b *UpdateBuilder) Set(values interface{}) *UpdateBuilder {
+	cols, vals, err := rowData(values)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.cols = append(b.cols, cols...)
+	b.vals = append(b.vals, vals...)
+	return b
+}
+
+// Where add condition, as SelectBuilder.Where does, but conditions with empty array argument
+// are not skipped, Exec returns ErrEmptyArrayInStatement instead
+func (b *UpdateBuilder) Where(cond interface{}, args ...interface{}) *UpdateBuilder {
+	c, err := toCond(cond, args)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.where = append(b.where, c)
+	return b
+}
+
+// All update all rows of table without where clause
+func (b *UpdateBuilder) All() *UpdateBuilder {
+	b.all = true
+	return b
+}
+
+// ToSQL statement with `?` placeholders and its arguments
+func (b *UpdateBuilder) ToSQL() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if b.table == "" || len(b.cols) == 0 {
+		return "", nil, ErrStatement
+	}
+	sets := make([]string, len(b.cols))
+	for i, c := range b.cols {
+		sets[i] = quoteIdent(b.driver, c) + "=?"
+	}
+	where, args, err := writeWhereFmt(b.where, b.all)
+	if err != nil {
+		return "", nil, err
+	}
+	return "UPDATE " + quoteIdent(b.driver, b.table) + " SET " + strings.Join(sets, ", ") + where,
+		append(append([]interface{}{}, b.vals...), args...), nil
+}
+
+// Format statement with arguments formatted by dialect
+func (b *UpdateBuilder) Format() (string, error) {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return "", err
+	}
+	return statementFormat(query, b.argFmt, args...)
+}
+
+// Exec execute update statement
+func (b *UpdateBuilder) Exec(ctx context.Context) (*Affected, error) {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	return b.exec(ctx, query, args...)
+}
+
+// DeleteBuilder build delete statement, Where or All is required
+type DeleteBuilder struct {
+	driver dbDriver
+	argFmt argFormat
+	exec   execFunc
+	table  string
+	where  []Cond
+	all    bool
+	err    error
+}
+
+// DeleteFrom build delete statement of table
+func (s *SqlY) DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{driver: s.driver, argFmt: s.argFmt, exec: s.ExecCtx, table: table}
+}
+
+// Where add condition, as SelectBuilder.Where does, but conditions with empty array argument
+// are not skipped, Exec returns ErrEmptyArrayInStatement instead
+func (b *DeleteBuilder) Where(cond interface{}, args ...interface{}) *DeleteBuilder {
+	c, err := toCond(cond, args)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.where = append(b.where, c)
+	return b
+}
+
+// All delete all rows of table without where clause
+func (b *DeleteBuilder) All() *DeleteBuilder {
+	b.all = true
+	return b
+}
+
+// ToSQL statement with `?` placeholders and its arguments
+func (b *DeleteBuilder) ToSQL() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if b.table == "" {
+		return "", nil, ErrStatement
+	}
+	where, args, err := writeWhereFmt(b.where, b.all)
+	if err != nil {
+		return "", nil, err
+	}
+	return "DELETE FROM " + quoteIdent(b.driver, b.table) + where, args, nil
+}
+
+// Format statement with arguments formatted by dialect
+func (b *DeleteBuilder) Format() (string, error) {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return "", err
+	}
+	return statementFormat(query, b.argFmt, args...)
+}
+
+// Exec execute delete statement
+func (b *DeleteBuilder) Exec(ctx context.Context) (*Affected, error) {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	return b.exec(ctx, query, args...)
+}
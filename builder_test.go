@@ -0,0 +1,202 @@
+package sqly
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSelectBuilder(t *testing.T) {
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, nil)
+	b := db.Select("id", "nickname").From("account").
+		Where("role = ?", 1).
+		Where(Or(In("mobile", []string{"18812311231", "18812311232"}), IsNull("mobile"))).
+		Where(In("id", []int64{})).
+		Where(And(Between("create_time", "2020-01-01", "2020-12-31"), In("email", []string{}))).
+		OrderBy("id DESC").Limit(10).Offset(20)
+	query, args, err := b.ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp := "SELECT id, nickname FROM account WHERE role = ? AND (mobile IN ? OR mobile IS NULL) " +
+		"AND create_time BETWEEN ? AND ? ORDER BY id DESC LIMIT 10 OFFSET 20"
+	if query != cmp || len(args) != 4 {
+		t.Errorf("got %s %v", query, args)
+	}
+	query, err = b.Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp = "SELECT id, nickname FROM account WHERE role = 1 AND (mobile IN ('18812311231','18812311232') OR mobile IS NULL) " +
+		"AND create_time BETWEEN '2020-01-01' AND '2020-12-31' ORDER BY id DESC LIMIT 10 OFFSET 20"
+	if query != cmp {
+		t.Errorf("got %s", query)
+	}
+
+	// all conditions are skipped
+	query, _, _ = db.Select().From("account").Where(In("id", []int64{})).ToSQL()
+	if query != "SELECT * FROM account" {
+		t.Errorf("got %s", query)
+	}
+	if _, _, err := db.Select().ToSQL(); err != ErrStatement {
+		t.Errorf("expect ErrStatement, got %v", err)
+	}
+	// unsupported type of condition
+	if _, _, err := db.Select().From("account").Where(42).ToSQL(); err != ErrArgType {
+		t.Errorf("expect ErrArgType, got %v", err)
+	}
+	if _, _, err := db.Select().From("account").GroupBy("role").Having([]string{"a"}).ToSQL(); err != ErrArgType {
+		t.Errorf("expect ErrArgType, got %v", err)
+	}
+}
+
+func TestMssql_SelectBuilder(t *testing.T) {
+	db := newFakeDb(t, fakeMssql, "sqlserver", driverMssql, nil)
+	query, _, err := db.Select("id").From("account").Where("role = ?", 1).Limit(5).ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "SELECT TOP 5 id FROM account WHERE role = ?" {
+		t.Errorf("got %s", query)
+	}
+	query, _, _ = db.Select("id").From("account").OrderBy("id").Limit(5).Offset(10).ToSQL()
+	if query != "SELECT id FROM account ORDER BY id OFFSET 10 ROWS FETCH NEXT 5 ROWS ONLY" {
+		t.Errorf("got %s", query)
+	}
+}
+
+func TestSqlite_SelectBuilder(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+	_, err := db.InsertMany("INSERT INTO `account` (`nickname`, `mobile`, `role`) VALUES (?, ?, ?)", [][]interface{}{
+		{"lucy", "18812311231", 1}, {"lily", "18812311232", 2}, {"lucas", "18812311233", 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accs []*Account
+	err = db.Select().From("`account`").Where("`role` = ?", 2).Where(In("`mobile`", []string{})).
+		OrderBy("`id` DESC").Limit(1).Query(ctx, &accs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accs) != 1 || accs[0].Nickname != "lucas" {
+		t.Errorf("unexpected accounts %v", accs)
+	}
+	var nickname string
+	capsule := NewCapsule(db)
+	err = capsule.Select("`nickname`").From("`account`").Where(In("`id`", []int64{1})).Get(ctx, &nickname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nickname != "lucy" {
+		t.Errorf("got %s", nickname)
+	}
+}
+
+func TestWriteBuilders(t *testing.T) {
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, nil)
+	type account struct {
+		ID       int64  `sql:"id,pk"`
+		Nickname string `sql:"nickname"`
+		Mobile   string `sql:"mobile"`
+	}
+	query, err := db.InsertInto("account").Rows([]*account{
+		{Nickname: "lucy", Mobile: "18812311231"}, {Nickname: "lily", Mobile: "18812311232"},
+	}).Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp := "INSERT INTO `account` (`nickname`, `mobile`) VALUES ('lucy', '18812311231'),('lily', '18812311232');"
+	if query != cmp {
+		t.Errorf("got %s", query)
+	}
+	query, err = db.InsertInto("account").Columns("mobile", "nickname").
+		Rows(map[string]interface{}{"nickname": "lucy", "mobile": "18812311231", "role": 1}).
+		Values("18812311232", "lily").Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp = "INSERT INTO `account` (`mobile`, `nickname`) VALUES ('18812311231', 'lucy'),('18812311232', 'lily');"
+	if query != cmp {
+		t.Errorf("got %s", query)
+	}
+	if _, err := db.InsertInto("account").Columns("email").Rows(&account{}).Format(); err != ErrFieldsMatch {
+		t.Errorf("expect ErrFieldsMatch, got %v", err)
+	}
+
+	query, err = db.UpdateTable("account").Set(map[string]interface{}{"nickname": "lucy", "role": 2}).
+		Where(In("id", []int64{1, 2})).Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "UPDATE `account` SET `nickname`='lucy', `role`=2 WHERE id IN (1,2)" {
+		t.Errorf("got %s", query)
+	}
+	// conditions with empty array are not skipped for update and delete
+	if _, err := db.UpdateTable("account").Set(map[string]interface{}{"role": 2}).
+		Where(In("id", []int64{})).Exec(context.TODO()); err != ErrEmptyArrayInStatement {
+		t.Errorf("expect ErrEmptyArrayInStatement, got %v", err)
+	}
+	if _, err := db.UpdateTable("account").Set(map[string]interface{}{"role": 2}).Where("role = ?", 1).
+		Where(Or(IsNull("mobile"), In("id", []int64{}))).Exec(context.TODO()); err != ErrEmptyArrayInStatement {
+		t.Errorf("expect ErrEmptyArrayInStatement, got %v", err)
+	}
+	if _, err := db.DeleteFrom("account").Where(In("id", []int64{})).Where("role = ?", 1).
+		Exec(context.TODO()); err != ErrEmptyArrayInStatement {
+		t.Errorf("expect ErrEmptyArrayInStatement, got %v", err)
+	}
+	if _, err := db.UpdateTable("account").Set(map[string]interface{}{"role": 2}).Where(42).
+		Exec(context.TODO()); err != ErrArgType {
+		t.Errorf("expect ErrArgType, got %v", err)
+	}
+	if _, err := db.DeleteFrom("account").Where(int64(1)).All().Exec(context.TODO()); err != ErrArgType {
+		t.Errorf("expect ErrArgType, got %v", err)
+	}
+	query, _ = db.UpdateTable("account").Set(map[string]interface{}{"role": 2}).All().Format()
+	if query != "UPDATE `account` SET `role`=2" {
+		t.Errorf("got %s", query)
+	}
+
+	if _, err := db.DeleteFrom("account").Exec(context.TODO()); err != ErrNoWhere {
+		t.Errorf("expect ErrNoWhere, got %v", err)
+	}
+	query, _ = db.DeleteFrom("account").Where("id = ?", 1).Format()
+	if query != "DELETE FROM `account` WHERE id = 1" {
+		t.Errorf("got %s", query)
+	}
+	if qs := fakeMysql.recorded(); len(qs) != 0 {
+		t.Errorf("unexpected statements %v", qs)
+	}
+}
+
+func TestSqlite_WriteBuilders(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+	aff, err := db.InsertInto("account").Columns("nickname", "mobile").
+		Values("lucy", "18812311231").Values("lily", "18812311232").Exec(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := aff.GetRowsAffected(); n != 2 {
+		t.Errorf("rows affected %d", n)
+	}
+	_, err = db.Transaction(func(tx *Trans) (interface{}, error) {
+		if _, err := tx.UpdateTable("account").Set(map[string]interface{}{"role": 3}).
+			Where("`mobile` = ?", "18812311232").Exec(ctx); err != nil {
+			return nil, err
+		}
+		return tx.DeleteFrom("account").Where(In("`mobile`", []string{"18812311231"})).Exec(ctx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accs []*Account
+	if err := db.Select().From("`account`").Query(ctx, &accs); err != nil {
+		t.Fatal(err)
+	}
+	if len(accs) != 1 || accs[0].Mobile != "18812311232" || accs[0].Role.Int32 != 3 {
+		t.Errorf("unexpected accounts %v", accs)
+	}
+}
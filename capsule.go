@@ -193,6 +193,212 @@ func (c *Capsule) PgExec(ctx context.Context, idField, query string, args ...int
 	}
 }
 
+// ExecReturning exec statement with RETURNING clause, and scan the returned rows into dest
+func (c *Capsule) ExecReturning(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return err
+	}
+	if cs.isTrans {
+		return cs.tx.ExecReturning(ctx, dest, query, args...)
+	}
+	return cs.conn.ExecReturning(ctx, dest, query, args...)
+}
+
+// InsertManyReturningIDs insert many rows, and return all the generated ids in order of rows
+func (c *Capsule) InsertManyReturningIDs(ctx context.Context, idField, query string, args [][]interface{}) ([]int64, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cs.isTrans {
+		return cs.tx.InsertManyReturningIDs(ctx, idField, query, args)
+	}
+	return cs.conn.InsertManyReturningIDs(ctx, idField, query, args)
+}
+
+// Upsert insert row, or update it on conflict of conflictCols
+func (c *Capsule) Upsert(ctx context.Context, table string, conflictCols []string, row interface{},
+	updateCols ...string) (UpsertStatus, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return UpsertUnknown, err
+	}
+	if cs.isTrans {
+		return cs.tx.Upsert(ctx, table, conflictCols, row, updateCols...)
+	}
+	return cs.conn.Upsert(ctx, table, conflictCols, row, updateCols...)
+}
+
+// UpsertMany upsert rows in one statement
+func (c *Capsule) UpsertMany(ctx context.Context, table string, conflictCols []string, rows interface{},
+	updateCols ...string) ([]UpsertStatus, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cs.isTrans {
+		return cs.tx.UpsertMany(ctx, table, conflictCols, rows, updateCols...)
+	}
+	return cs.conn.UpsertMany(ctx, table, conflictCols, rows, updateCols...)
+}
+
+// InsertStruct insert row (struct pointer) into table, the generated id is set to row
+func (c *Capsule) InsertStruct(ctx context.Context, table string, row interface{}) (*Affected, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cs.isTrans {
+		return cs.tx.InsertStruct(ctx, table, row)
+	}
+	return cs.conn.InsertStruct(ctx, table, row)
+}
+
+// UpdateStruct update all columns of row (struct pointer), keyed by primary keys
+func (c *Capsule) UpdateStruct(ctx context.Context, table string, row interface{}) (*Affected, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cs.isTrans {
+		return cs.tx.UpdateStruct(ctx, table, row)
+	}
+	return cs.conn.UpdateStruct(ctx, table, row)
+}
+
+// DeleteByPK delete row (struct pointer) keyed by primary keys
+func (c *Capsule) DeleteByPK(ctx context.Context, table string, row interface{}) (*Affected, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cs.isTrans {
+		return cs.tx.DeleteByPK(ctx, table, row)
+	}
+	return cs.conn.DeleteByPK(ctx, table, row)
+}
+
+// UpdateChanged update the fields of row (struct pointer) which are modified since tr.Track
+func (c *Capsule) UpdateChanged(ctx context.Context, tr *Tracker, table string, row interface{}) (*Affected, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cs.isTrans {
+		return cs.tx.UpdateChanged(ctx, tr, table, row)
+	}
+	return cs.conn.UpdateChanged(ctx, tr, table, row)
+}
+
+// Select build select statement, it's queried in transaction if the capsule is
+func (c *Capsule) Select(cols ...string) *SelectBuilder {
+	return newSelect(c.sqlY.driver, c.sqlY.argFmt, c.Query, c.Get, cols)
+}
+
+// InsertInto build insert statement, it's executed in transaction if the capsule is
+func (c *Capsule) InsertInto(table string) *InsertBuilder {
+	return &InsertBuilder{driver: c.sqlY.driver, argFmt: c.sqlY.argFmt, execMany: c.InsertMany, table: table}
+}
+
+// UpdateTable build update statement, it's executed in transaction if the capsule is
+func (c *Capsule) UpdateTable(table string) *UpdateBuilder {
+	return &UpdateBuilder{driver: c.sqlY.driver, argFmt: c.sqlY.argFmt, exec: c.Exec, table: table}
+}
+
+// DeleteFrom build delete statement, it's executed in transaction if the capsule is
+func (c *Capsule) DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{driver: c.sqlY.driver, argFmt: c.sqlY.argFmt, exec: c.Exec, table: table}
+}
+
+// Paginate query rows of page to dest (slice pointer), by offset or keyset
+func (c *Capsule) Paginate(ctx context.Context, dest interface{}, query string, opts PageOpts,
+	args ...interface{}) (*Page, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cs.isTrans {
+		return cs.tx.Paginate(ctx, dest, query, opts, args...)
+	}
+	return cs.conn.Paginate(ctx, dest, query, opts, args...)
+}
+
+// CopyFrom bulk load rows into table with COPY FROM of postgresql, in the transaction of capsule if it's active
+func (c *Capsule) CopyFrom(ctx context.Context, table string, columns []string, source interface{}) (int64, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if cs.isTrans {
+		return cs.tx.CopyFrom(ctx, table, columns, source)
+	}
+	return cs.conn.CopyFrom(ctx, table, columns, source)
+}
+
+// LoadData bulk load rows into table with LOAD DATA LOCAL INFILE of mysql
+func (c *Capsule) LoadData(ctx context.Context, table string, columns []string, source interface{}) (*Affected, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cs.isTrans {
+		return cs.tx.LoadData(ctx, table, columns, source)
+	}
+	return cs.conn.LoadData(ctx, table, columns, source)
+}
+
+// Lock acquire the lock of key which is released at the end of transaction, capsule should be in transaction
+func (c *Capsule) Lock(ctx context.Context, key string) error {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return err
+	}
+	if !cs.isTrans {
+		return ErrNoTransaction
+	}
+	return cs.tx.Lock(ctx, key)
+}
+
+// TryLock acquire the lock of key which is released at the end of transaction if it's not held by others,
+// capsule should be in transaction
+func (c *Capsule) TryLock(ctx context.Context, key string) (bool, error) {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !cs.isTrans {
+		return false, ErrNoTransaction
+	}
+	return cs.tx.TryLock(ctx, key)
+}
+
+// GetForUpdate query rows with locking clause of opts, capsule should be in transaction
+func (c *Capsule) GetForUpdate(ctx context.Context, dest interface{}, query string, opts RowLockOpts,
+	args ...interface{}) error {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return err
+	}
+	if !cs.isTrans {
+		return ErrNoTransaction
+	}
+	return cs.tx.GetForUpdate(ctx, dest, query, opts, args...)
+}
+
+// ClaimBatch claim up to n rows of table matching cond, mark them and query them to dest
+func (c *Capsule) ClaimBatch(ctx context.Context, n int64, dest interface{}, table string, cond Cond,
+	mark interface{}) error {
+	cs, err := c.getCapsule(ctx)
+	if err != nil {
+		return err
+	}
+	if cs.isTrans {
+		return cs.tx.ClaimBatch(ctx, n, dest, table, cond, mark)
+	}
+	return cs.conn.ClaimBatch(ctx, n, dest, table, cond, mark)
+}
+
 // Close close connection
 func (c *Capsule) Close() error {
 	return c.sqlY.Close()
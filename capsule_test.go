@@ -8,31 +8,14 @@ import (
 )
 
 func TestCapsule_Exec(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	ctx := context.TODO()
 	_, err = capsule.StartCapsule(ctx, true, func(ctx context.Context) (interface{}, error) {
-		query := "DROP TABLE IF EXISTS `account`;" +
-			"CREATE TABLE `account` (" +
-			"`id` int(10) unsigned NOT NULL AUTO_INCREMENT," +
-			"`nickname` varchar(32) COLLATE utf8mb4_unicode_ci NOT NULL," +
-			"`avatar` varchar(200) COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT 'avatar url'," +
-			"`mobile` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'mobile number'," +
-			"`email` varchar(320) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT 'email'," +
-			"`password` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT 'password'," +
-			"`role` tinyint(4) DEFAULT '0' COMMENT 'role'," +
-			"`is_valid` tinyint(4) DEFAULT NULL COMMENT 'is_valid'," +
-			"`stature` float(5,2) DEFAULT NULL COMMENT 'stature'," +
-			"`create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP," +
-			"`add_time` datetime DEFAULT NULL, " +
-			"`birthday` date DEFAULT NULL, " +
-			"PRIMARY KEY (`id`)," +
-			"UNIQUE KEY `mobile_index` (`mobile`)," +
-			"KEY `email_index` (`email`)" +
-			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;"
+		query := accountTable(db)
 		_, err = capsule.Exec(ctx, query)
 		return nil, err
 	})
@@ -42,9 +25,9 @@ func TestCapsule_Exec(t *testing.T) {
 }
 
 func TestCapsule_InsertUpdate(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	ctx := context.TODO()
@@ -79,13 +62,13 @@ func TestCapsule_InsertUpdate(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(ret)
+	fmt.Println(ret)
 }
 
 func TestCapsule_Delete(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	ctx := context.TODO()
@@ -107,13 +90,13 @@ func TestCapsule_Delete(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(ret)
+	fmt.Println(ret)
 }
 
 func TestCapsule_trans(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	ctx := context.TODO()
@@ -143,13 +126,13 @@ func TestCapsule_trans(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(ret)
+	fmt.Println(ret)
 }
 
 func TestCapsule_trans2(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	ctx := context.TODO()
@@ -188,13 +171,13 @@ func TestCapsule_trans2(t *testing.T) {
 	if err != nil && err.Error() != "error" {
 		t.Error(err)
 	}
-	fmt.Sprintln(ret)
+	fmt.Println(ret)
 }
 
 func TestCapsule_raw1(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	ctx := context.TODO()
@@ -233,13 +216,13 @@ func TestCapsule_raw1(t *testing.T) {
 	if err != nil && err.Error() != "error" {
 		t.Error(err)
 	}
-	fmt.Sprintln(ret)
+	fmt.Println(ret)
 }
 
 func TestCapsule_raw2(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	ctx := context.TODO()
@@ -283,13 +266,13 @@ func TestCapsule_raw2(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(ret)
+	fmt.Println(ret)
 }
 
 func TestCapsule_raw3(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	ctx := context.TODO()
@@ -319,13 +302,13 @@ func TestCapsule_raw3(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(aff)
+	fmt.Println(aff)
 }
 
 func TestCapsule_Close(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	err = capsule.Close()
@@ -335,9 +318,9 @@ func TestCapsule_Close(t *testing.T) {
 }
 
 func TestCapsule_IsTrans(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	capsule := NewCapsule(db)
 	ctx := context.TODO()
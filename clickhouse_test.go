@@ -0,0 +1,110 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newClickhouseDb(t *testing.T, handler fakeHandler) *SqlY {
+	fakeClickhouse.reset(handler)
+	db, err := New(&Option{Dsn: "tcp://fake:9000", DriverName: "clickhouse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestQueryFmtClickhouse(t *testing.T) {
+	tm := time.Date(2021, 5, 1, 16, 30, 0, 123000000, time.FixedZone("CST", 8*3600))
+	res, err := QueryFmtClickhouse("INSERT INTO events (name, tags, ids, point, ts) VALUES (?, ?, ?, ?, ?)",
+		"it's", Array([]string{"a", "b'c"}), Array([]int64{1, 2}), Tuple{1.5, "x"}, tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resCmp := "INSERT INTO events (name, tags, ids, point, ts) VALUES ('it\\'s', ['a','b\\'c'], [1,2], (1.5,'x'), " +
+		"toDateTime64('2021-05-01 08:30:00.123', 9, 'UTC'))"
+	if res != resCmp {
+		t.Errorf("got %s", res)
+	}
+}
+
+func TestClickhouse_InsertMany(t *testing.T) {
+	db := newClickhouseDb(t, nil)
+	query := "INSERT INTO events (name, tags, ts) VALUES (?, ?, ?);"
+	tm := time.Now()
+	args := [][]interface{}{
+		{"click", Array([]string{"a", "b"}), tm},
+		{NullString{String: "view", Valid: true}, Array([]string{}), NullTime{}},
+	}
+	var got [][]driver.NamedValue
+	fakeClickhouse.reset(func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if len(args) > 0 {
+			got = append(got, args)
+		}
+		return &fakeResult{}, nil
+	})
+	aff, err := db.InsertManyCtx(context.TODO(), query, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := aff.GetRowsAffected(); !errors.Is(err, ErrNotSupportForThisDriver) {
+		t.Errorf("rows affected err %v", err)
+	}
+	if _, err := aff.GetLastId(); !errors.Is(err, ErrNotSupportForThisDriver) {
+		t.Errorf("last id err %v", err)
+	}
+	qs := fakeClickhouse.recorded()
+	cmp := []string{"BEGIN", "INSERT INTO events (name, tags, ts) VALUES (?, ?, ?)",
+		"INSERT INTO events (name, tags, ts) VALUES (?, ?, ?)", "COMMIT"}
+	if len(qs) != len(cmp) {
+		t.Fatalf("got %v", qs)
+	}
+	for i := range cmp {
+		if qs[i] != cmp[i] {
+			t.Errorf("got %s", qs[i])
+		}
+	}
+	if len(got) != 2 || got[1][0].Value != "view" || got[1][2].Value != nil {
+		t.Errorf("got args %v", got)
+	}
+}
+
+func TestClickhouse_QueryMap(t *testing.T) {
+	db := newClickhouseDb(t, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{
+			columns: []fakeColumn{
+				{name: "id", typeName: "UInt64"},
+				{name: "name", typeName: "LowCardinality(String)"},
+				{name: "user_id", typeName: "Nullable(Int64)"},
+				{name: "ts", typeName: "DateTime64(3, 'UTC')"},
+				{name: "amount", typeName: "Nullable(UInt64)"},
+			},
+			rows: [][]driver.Value{{uint64(1 << 63), "click", nil, time.Now(), uint64(1<<64 - 1)}},
+		}, nil
+	})
+	var res []map[string]interface{}
+	if err := db.Query(&res, "SELECT * FROM events"); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("got %d rows", len(res))
+	}
+	if v, ok := res[0]["id"].(*uint64); !ok || *v != 1<<63 {
+		t.Errorf("id %v", res[0]["id"])
+	}
+	if v, ok := res[0]["name"].(*string); !ok || *v != "click" {
+		t.Errorf("name %v", res[0]["name"])
+	}
+	if v, ok := res[0]["user_id"].(*NullInt64); !ok || v.Valid {
+		t.Errorf("user_id %v", res[0]["user_id"])
+	}
+	if _, ok := res[0]["ts"].(*time.Time); !ok {
+		t.Errorf("ts %v", res[0]["ts"])
+	}
+	if v, ok := res[0]["amount"].(*NullUint64); !ok || !v.Valid || v.Uint64 != 1<<64-1 {
+		t.Errorf("amount %v", res[0]["amount"])
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/FeifeiyuM/sqly"
+)
+
+// table to generate
+type genTable struct {
+	*sqly.Table
+	Columns []*sqly.Column
+}
+
+// options of generator
+type genOpts struct {
+	pkg     string // package name
+	driver  string // dialect, mysql, postgres, sqlite3, sqlserver
+	json    bool   // add json tags
+	queries bool   // generate query constants of tables
+}
+
+// common initialisms of go names
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "TCP": true, "TTL": true, "UDP": true, "UI": true,
+	"URI": true, "URL": true, "UTF8": true, "UUID": true, "XML": true,
+}
+
+// goName exported go name of sql name, such as user_id to UserID
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	var b strings.Builder
+	for _, w := range words {
+		if u := strings.ToUpper(w); initialisms[u] {
+			b.WriteString(u)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	res := b.String()
+	if res == "" || res[0] >= '0' && res[0] <= '9' {
+		res = "X" + res
+	}
+	return res
+}
+
+// integer types
+var (
+	int32Types = map[string]bool{
+		"tinyint": true, "smallint": true, "mediumint": true, "int": true, "integer": true, "year": true,
+		"int2": true, "int4": true, "smallserial": true, "serial": true, "serial2": true, "serial4": true,
+	}
+	int64Types = map[string]bool{"bigint": true, "int8": true, "bigserial": true, "serial8": true}
+)
+
+// go type of postgresql array, element type is the type name without `_`
+func arrayType(elem string) string {
+	switch elem {
+	case "int2", "int4":
+		return "sqly.Int32Array"
+	case "int8":
+		return "sqly.Int64Array"
+	case "float4":
+		return "sqly.Float32Array"
+	case "float8", "numeric":
+		return "sqly.Float64Array"
+	case "bool":
+		return "sqly.BoolArray"
+	case "bytea":
+		return "sqly.ByteaArray"
+	}
+	return "sqly.StringArray"
+}
+
+// goType go type of column, nullable columns use sqly null types
+func goType(driver string, col *sqly.Column) string {
+	dt := strings.ToLower(col.DataType)
+	ct := strings.ToLower(col.ColumnType)
+	if driver == "postgres" && strings.HasPrefix(dt, "_") {
+		return arrayType(dt[1:])
+	}
+	var typ, null string
+	switch {
+	case dt == "tinyint" && strings.HasPrefix(ct, "tinyint(1)"), dt == "bool", dt == "boolean", dt == "bit" && driver == "sqlserver":
+		typ, null = "bool", "sqly.NullBool"
+	case int64Types[dt] || int32Types[dt] && strings.Contains(ct, "unsigned") ||
+		driver == "sqlite3" && strings.Contains(dt, "int"):
+		// integer of sqlite is 64 bits
+		typ, null = "int64", "sqly.NullInt64"
+	case int32Types[dt]:
+		typ, null = "int32", "sqly.NullInt32"
+	case strings.Contains(dt, "float") || strings.Contains(dt, "double") || dt == "real" ||
+		dt == "decimal" || dt == "numeric" || strings.Contains(dt, "money"):
+		typ, null = "float64", "sqly.NullFloat64"
+	case strings.Contains(dt, "date") || strings.Contains(dt, "timestamp"):
+		typ, null = "time.Time", "sqly.NullTime"
+	case strings.Contains(dt, "blob") || strings.Contains(dt, "binary") || dt == "bytea" || dt == "image" ||
+		driver == "sqlite3" && dt == "":
+		return "[]byte"
+	default:
+		typ, null = "string", "sqly.NullString"
+	}
+	if col.Nullable && !col.PrimaryKey {
+		return null
+	}
+	return typ
+}
+
+// quote identifier of dialect
+func quote(driver, name string) string {
+	switch driver {
+	case "postgres", "sqlite3":
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	case "sqlserver":
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// single line comment
+func comment(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// write struct of table, packages of field types are added to imports
+func writeStruct(b *bytes.Buffer, opts genOpts, t *genTable, imports map[string]bool) {
+	name := goName(t.Name)
+	if c := comment(t.Comment); c != "" {
+		fmt.Fprintf(b, "// %s %s\n", name, c)
+	} else {
+		fmt.Fprintf(b, "// %s %s %s\n", name, t.Type, t.Name)
+	}
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, col := range t.Columns {
+		tag := col.Name
+		if col.PrimaryKey {
+			tag += ",pk"
+		}
+		typ := goType(opts.driver, col)
+		if strings.HasPrefix(typ, "time.") {
+			imports["time"] = true
+		} else if strings.HasPrefix(typ, "sqly.") {
+			imports["github.com/FeifeiyuM/sqly"] = true
+		}
+		fmt.Fprintf(b, "\t%s %s `sql:%s", goName(col.Name), typ, strconv.Quote(tag))
+		if opts.json {
+			fmt.Fprintf(b, " json:%s", strconv.Quote(col.Name))
+		}
+		b.WriteString("`")
+		if c := comment(col.Comment); c != "" {
+			b.WriteString(" // " + c)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n\n")
+}
+
+// write query constants of table, statements use `?` placeholders of sqly
+func writeQueries(b *bytes.Buffer, opts genOpts, t *genTable) {
+	name := goName(t.Name)
+	table := quote(opts.driver, t.Name)
+	var cols, insertCols, sets, pkConds []string
+	for _, col := range t.Columns {
+		q := quote(opts.driver, col.Name)
+		cols = append(cols, q)
+		if !col.AutoIncrement {
+			insertCols = append(insertCols, q)
+		}
+		if col.PrimaryKey {
+			pkConds = append(pkConds, q+" = ?")
+		} else {
+			sets = append(sets, q+" = ?")
+		}
+	}
+	selectAll := "SELECT " + strings.Join(cols, ", ") + " FROM " + table
+	consts := [][2]string{
+		{"Table", t.Name},
+		{"Columns", strings.Join(cols, ", ")},
+		{"Select", selectAll},
+	}
+	if t.Type == "table" {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(insertCols)), ", ")
+		consts = append(consts, [2]string{"Insert",
+			"INSERT INTO " + table + " (" + strings.Join(insertCols, ", ") + ") VALUES (" + placeholders + ")"})
+		if len(pkConds) > 0 {
+			where := " WHERE " + strings.Join(pkConds, " AND ")
+			consts = append(consts, [2]string{"GetByPK", selectAll + where})
+			if len(sets) > 0 {
+				consts = append(consts, [2]string{"UpdateByPK", "UPDATE " + table + " SET " + strings.Join(sets, ", ") + where})
+			}
+			consts = append(consts, [2]string{"DeleteByPK", "DELETE FROM " + table + where})
+		}
+	}
+	fmt.Fprintf(b, "// queries of %s\nconst (\n", t.Name)
+	for _, c := range consts {
+		fmt.Fprintf(b, "\t%s%s = %s\n", name, c[0], strconv.Quote(c[1]))
+	}
+	b.WriteString(")\n\n")
+}
+
+// generate gofmt-ed go source of tables, tables and columns are written in order,
+// so the output is reproducible
+func generate(opts genOpts, tables []*genTable) ([]byte, error) {
+	tables = append([]*genTable(nil), tables...)
+	sort.SliceStable(tables, func(i, j int) bool {
+		return tables[i].Name < tables[j].Name
+	})
+	var body bytes.Buffer
+	imports := make(map[string]bool)
+	for _, t := range tables {
+		writeStruct(&body, opts, t, imports)
+		if opts.queries {
+			writeQueries(&body, opts, t)
+		}
+	}
+	var b bytes.Buffer
+	b.WriteString("// Code generated by sqly-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", opts.pkg)
+	// standard library first
+	var groups []string
+	for _, p := range []string{"time", "github.com/FeifeiyuM/sqly"} {
+		if imports[p] {
+			groups = append(groups, strconv.Quote(p))
+		}
+	}
+	if len(groups) > 0 {
+		b.WriteString("import (\n\t" + strings.Join(groups, "\n\n\t") + "\n)\n\n")
+	}
+	b.Write(body.Bytes())
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/FeifeiyuM/sqly"
+)
+
+func TestGoName(t *testing.T) {
+	cases := map[string]string{
+		"account":     "Account",
+		"user_id":     "UserID",
+		"avatar_url":  "AvatarURL",
+		"create-time": "CreateTime",
+		"2fa_secret":  "X2faSecret",
+	}
+	for name, cmp := range cases {
+		if res := goName(name); res != cmp {
+			t.Errorf("got %s, want %s", res, cmp)
+		}
+	}
+}
+
+func TestGeneratePostgres(t *testing.T) {
+	tables := []*genTable{{
+		Table: &sqly.Table{Name: "account", Type: "table", Comment: "user account"},
+		Columns: []*sqly.Column{
+			{Name: "id", DataType: "int8", PrimaryKey: true, AutoIncrement: true},
+			{Name: "nickname", DataType: "varchar", Comment: "display\nname"},
+			{Name: "avatar", DataType: "text", Nullable: true},
+			{Name: "role", DataType: "int2", Nullable: true},
+			{Name: "tags", DataType: "_text", Nullable: true},
+			{Name: "scores", DataType: "_int8"},
+			{Name: "create_time", DataType: "timestamptz"},
+			{Name: "birthday", DataType: "date", Nullable: true},
+		},
+	}}
+	src, err := generate(genOpts{pkg: "model", driver: "postgres", queries: true}, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp := `// Code generated by sqly-gen. DO NOT EDIT.
+
+package model
+
+import (
+	"time"
+
+	"github.com/FeifeiyuM/sqly"
+)
+
+// Account user account
+type Account struct {
+	ID         int64            ` + "`sql:\"id,pk\"`" + `
+	Nickname   string           ` + "`sql:\"nickname\"`" + ` // display name
+	Avatar     sqly.NullString  ` + "`sql:\"avatar\"`" + `
+	Role       sqly.NullInt32   ` + "`sql:\"role\"`" + `
+	Tags       sqly.StringArray ` + "`sql:\"tags\"`" + `
+	Scores     sqly.Int64Array  ` + "`sql:\"scores\"`" + `
+	CreateTime time.Time        ` + "`sql:\"create_time\"`" + `
+	Birthday   sqly.NullTime    ` + "`sql:\"birthday\"`" + `
+}
+
+// queries of account
+const (
+	AccountTable      = "account"
+	AccountColumns    = "\"id\", \"nickname\", \"avatar\", \"role\", \"tags\", \"scores\", \"create_time\", \"birthday\""
+	AccountSelect     = "SELECT \"id\", \"nickname\", \"avatar\", \"role\", \"tags\", \"scores\", \"create_time\", \"birthday\" FROM \"account\""
+	AccountInsert     = "INSERT INTO \"account\" (\"nickname\", \"avatar\", \"role\", \"tags\", \"scores\", \"create_time\", \"birthday\") VALUES (?, ?, ?, ?, ?, ?, ?)"
+	AccountGetByPK    = "SELECT \"id\", \"nickname\", \"avatar\", \"role\", \"tags\", \"scores\", \"create_time\", \"birthday\" FROM \"account\" WHERE \"id\" = ?"
+	AccountUpdateByPK = "UPDATE \"account\" SET \"nickname\" = ?, \"avatar\" = ?, \"role\" = ?, \"tags\" = ?, \"scores\" = ?, \"create_time\" = ?, \"birthday\" = ? WHERE \"id\" = ?"
+	AccountDeleteByPK = "DELETE FROM \"account\" WHERE \"id\" = ?"
+)
+`
+	if string(src) != cmp {
+		t.Errorf("got\n%s", src)
+	}
+}
+
+func TestSqlite_Generate(t *testing.T) {
+	db, err := sqly.New(&sqly.Option{Dsn: "file::memory:", DriverName: "sqlite3", MaxIdleConns: 1, MaxOpenConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.ExecMany([]string{
+		"CREATE TABLE `order` (`id` INTEGER PRIMARY KEY AUTOINCREMENT, `no` VARCHAR(32) NOT NULL, " +
+			"`amount` DECIMAL(10, 2), `paid` BOOLEAN NOT NULL DEFAULT 0, `paid_at` DATETIME, `extra` BLOB)",
+		"CREATE TABLE `account` (`id` INTEGER PRIMARY KEY, `nickname` TEXT)",
+		"CREATE VIEW `paid_order` AS SELECT * FROM `order` WHERE `paid` = 1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+	tables, err := introspect(ctx, db, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].Name != "account" || tables[1].Name != "order" {
+		t.Fatalf("got %v", tables)
+	}
+	opts := genOpts{pkg: "model", driver: db.DriverName(), json: true}
+	src, err := generate(opts, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"type Order struct {",
+		"ID     int64            `sql:\"id,pk\" json:\"id\"`",
+		"No     string           `sql:\"no\" json:\"no\"`",
+		"Amount sqly.NullFloat64 `sql:\"amount\" json:\"amount\"`",
+		"Paid   bool             `sql:\"paid\" json:\"paid\"`",
+		"PaidAt sqly.NullTime    `sql:\"paid_at\" json:\"paid_at\"`",
+		"Extra  []byte           `sql:\"extra\" json:\"extra\"`",
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("%s not found in\n%s", s, src)
+		}
+	}
+	again, err := generate(opts, []*genTable{tables[1], tables[0]})
+	if err != nil || string(again) != string(src) {
+		t.Errorf("output is not reproducible, %v", err)
+	}
+
+	if tables, err = introspect(ctx, db, []string{"paid_order"}, false); err != nil || len(tables) != 1 {
+		t.Errorf("got %v %v", tables, err)
+	}
+	if _, err = introspect(ctx, db, []string{"nothing"}, false); err == nil {
+		t.Error("expect error of missing table")
+	}
+}
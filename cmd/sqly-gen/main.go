@@ -0,0 +1,109 @@
+// Command sqly-gen generates go structs with sql tags from tables of a live database.
+//
+//	sqly-gen -driver mysql -dsn 'user:pass@tcp(127.0.0.1:3306)/db?parseTime=true' -pkg model -out model/tables.go
+//
+// Nullable columns are generated as sqly null types (NullString, NullInt64, NullTime ...),
+// and arrays of postgresql as sqly arrays (StringArray, Int64Array ...), primary key columns are tagged with pk.
+// With -queries, constants of select, insert, update and delete statements are generated for each table.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/FeifeiyuM/sqly"
+	"github.com/FeifeiyuM/sqly/migrate"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	driver := flag.String("driver", "mysql", "database driver, mysql, postgres or sqlite3")
+	dsn := flag.String("dsn", os.Getenv("SQLY_DSN"), "database server name, default $SQLY_DSN")
+	pkg := flag.String("pkg", "model", "package name of generated file")
+	out := flag.String("out", "", "output file, default stdout")
+	tables := flag.String("tables", "", "comma separated tables to generate, default all tables")
+	views := flag.Bool("views", false, "generate structs of views")
+	jsonTag := flag.Bool("json", true, "add json tags")
+	queries := flag.Bool("queries", false, "generate query constants of tables")
+	flag.Parse()
+	if *dsn == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	db, err := sqly.New(&sqly.Option{Dsn: *dsn, DriverName: *driver})
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+	var names []string
+	if *tables != "" {
+		names = strings.Split(*tables, ",")
+	}
+	ts, err := introspect(context.Background(), db, names, *views)
+	if err != nil {
+		fail(err)
+	}
+	src, err := generate(genOpts{pkg: *pkg, driver: db.DriverName(), json: *jsonTag, queries: *queries}, ts)
+	if err != nil {
+		fail(err)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(*out, src, 0644)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "sqly-gen:", err)
+	os.Exit(1)
+}
+
+// tables and columns of database, names filter tables if it's not empty
+func introspect(ctx context.Context, db *sqly.SqlY, names []string, views bool) ([]*genTable, error) {
+	all, err := db.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool)
+	for _, n := range names {
+		want[strings.TrimSpace(n)] = true
+	}
+	var res []*genTable
+	for _, t := range all {
+		if len(want) > 0 && !want[t.Name] {
+			continue
+		}
+		// views and schema table of migrations are skipped unless they are listed
+		if len(want) == 0 && (t.Type == "view" && !views || t.Name == migrate.DefaultTable) {
+			continue
+		}
+		cols, err := db.Columns(ctx, t.Name)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &genTable{Table: t, Columns: cols})
+	}
+	if len(want) > 0 && len(res) < len(want) {
+		for _, t := range res {
+			delete(want, t.Name)
+		}
+		var missing []string
+		for n := range want {
+			missing = append(missing, n)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("tables not found: %s", strings.Join(missing, ", "))
+	}
+	return res, nil
+}
@@ -0,0 +1,110 @@
+// Command sqly-migrate applies versioned sql migrations in a directory.
+//
+//	sqly-migrate -driver mysql -dsn 'user:pass@tcp(127.0.0.1:3306)/db?parseTime=true' -dir migrations up
+//
+// Commands:
+//
+//	up      apply all pending migrations
+//	down    roll back the latest applied migration
+//	redo    roll back the latest applied migration and apply it again
+//	status  print status of migrations
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/FeifeiyuM/sqly"
+	"github.com/FeifeiyuM/sqly/migrate"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "usage: sqly-migrate [flags] up|down|redo|status\n\nflags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	driver := flag.String("driver", "mysql", "database driver, mysql, postgres or sqlite3")
+	dsn := flag.String("dsn", os.Getenv("SQLY_DSN"), "database server name, default $SQLY_DSN")
+	dir := flag.String("dir", "migrations", "directory of migrations")
+	table := flag.String("table", migrate.DefaultTable, "schema table recording applied versions")
+	timeout := flag.Duration("timeout", 0, "timeout of migration, 0 means no timeout")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 || *dsn == "" {
+		usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *driver, *dsn, *dir, *table, *timeout); err != nil {
+		fmt.Fprintln(os.Stderr, "sqly-migrate:", err)
+		os.Exit(1)
+	}
+}
+
+func run(cmd, driver, dsn, dir, table string, timeout time.Duration) error {
+	db, err := sqly.New(&sqly.Option{Dsn: dsn, DriverName: driver})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m, err := migrate.New(db, os.DirFS(dir), &migrate.Options{Table: table})
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	switch cmd {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migrations applied\n", n)
+	case "down":
+		v, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("migration %d rolled back\n", v)
+	case "redo":
+		v, err := m.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("migration %d redone\n", v)
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\tNOTE")
+		for _, s := range status {
+			appliedAt, note := "pending", ""
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				note = "modified"
+			} else if s.Missing {
+				note = "missing"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, appliedAt, note)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown command %s", cmd)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package sqly
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+)
+
+// RowSource iterator of rows for bulk loading, such as CopyFrom
+type RowSource interface {
+	// Next advance to the next row, false if there are no more rows or an error occurred
+	Next() bool
+	// Values of current row, in order of columns
+	Values() ([]interface{}, error)
+	// Err error occurred during iteration
+	Err() error
+}
+
+// rows of slice
+type sliceSource struct {
+	rows [][]interface{}
+	pos  int
+}
+
+func (s *sliceSource) Next() bool {
+	s.pos++
+	return s.pos <= len(s.rows)
+}
+
+func (s *sliceSource) Values() ([]interface{}, error) {
+	return s.rows[s.pos-1], nil
+}
+
+func (s *sliceSource) Err() error {
+	return nil
+}
+
+// rowSource source is [][]interface{}, slice of struct (struct pointer, map[string]interface{}) or RowSource,
+// columns of struct are taken from `sql` tags if columns is empty
+func rowSource(columns []string, source interface{}) ([]string, RowSource, error) {
+	switch src := source.(type) {
+	case RowSource:
+		return columns, src, nil
+	case [][]interface{}:
+		return columns, &sliceSource{rows: src}, nil
+	}
+	if v := reflect.Indirect(reflect.ValueOf(source)); v.Kind() != reflect.Slice {
+		return nil, nil, ErrContainer
+	}
+	cols, vals, err := rowsData(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(columns) == 0 {
+		return cols, &sliceSource{rows: vals}, nil
+	}
+	if vals, err = pickColumns(cols, vals, columns); err != nil {
+		return nil, nil, err
+	}
+	return columns, &sliceSource{rows: vals}, nil
+}
+
+// copy rows of source into table with COPY FROM STDIN of lib/pq in transaction
+func copyFrom(ctx context.Context, tx *sql.Tx, table string, columns []string, source interface{}) (int64, error) {
+	columns, src, err := rowSource(columns, source)
+	if err != nil {
+		return 0, err
+	}
+	if len(columns) == 0 {
+		return 0, ErrFieldsMatch
+	}
+	// statement of COPY FROM STDIN is executed by prepare of lib/pq, which is not imported to keep the package driver free
+	query := "COPY " + quoteIdent(driverPostgresql, table) + " (" + quoteIdents(driverPostgresql, columns) + ") FROM STDIN"
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+	var count int64
+	for src.Next() {
+		vals, err := src.Values()
+		if err != nil {
+			return 0, err
+		}
+		if len(vals) != len(columns) {
+			return 0, ErrFieldsMatch
+		}
+		if _, err = stmt.ExecContext(ctx, bindArgs(driverPostgresql, vals)...); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	if err := src.Err(); err != nil {
+		return 0, err
+	}
+	// flush buffered rows
+	res, err := stmt.ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		return n, nil
+	}
+	return count, nil
+}
@@ -0,0 +1,72 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type copySource struct {
+	n int
+}
+
+func (s *copySource) Next() bool {
+	s.n++
+	return s.n <= 3
+}
+
+func (s *copySource) Values() ([]interface{}, error) {
+	return []interface{}{fmt.Sprintf("user%d", s.n), NullInt32{Int32: int32(s.n), Valid: true}}, nil
+}
+
+func (s *copySource) Err() error {
+	return nil
+}
+
+func TestPostgres_CopyFrom(t *testing.T) {
+	var rows [][]driver.NamedValue
+	db := newFakeDb(t, fakePostgres, "fake_postgres", driverPostgresql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if strings.HasPrefix(query, "COPY") {
+			rows = append(rows, args)
+		}
+		return &fakeResult{}, nil
+	})
+	ctx := context.TODO()
+	n, err := db.CopyFrom(ctx, "public.account", []string{"nickname", "role"}, &copySource{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 rows and the flush
+	if n != 3 || len(rows) != 4 || rows[2][1].Value != int32(3) || len(rows[3]) != 0 {
+		t.Errorf("copied %d, got rows %v", n, rows)
+	}
+	qs := fakePostgres.recorded()
+	if qs[0] != "BEGIN" || qs[1] != `COPY "public"."account" ("nickname", "role") FROM STDIN` || qs[len(qs)-1] != "COMMIT" {
+		t.Errorf("got %v", qs)
+	}
+
+	type account struct {
+		ID       int64  `sql:"id,pk"`
+		Nickname string `sql:"nickname"`
+		Mobile   string `sql:"mobile"`
+	}
+	capsule := NewCapsule(db)
+	fakePostgres.reset(nil)
+	_, err = capsule.StartCapsule(ctx, true, func(ctx context.Context) (interface{}, error) {
+		return capsule.CopyFrom(ctx, "account", nil, []account{{ID: 1, Nickname: "lucy", Mobile: "18812311231"}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs = fakePostgres.recorded()
+	if len(qs) != 4 || qs[1] != `COPY "account" ("id", "nickname", "mobile") FROM STDIN` {
+		t.Errorf("got %v", qs)
+	}
+
+	mysql := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, nil)
+	if _, err := mysql.CopyFrom(ctx, "account", nil, [][]interface{}{}); err != ErrNotSupportForThisDriver {
+		t.Errorf("expect ErrNotSupportForThisDriver, got %v", err)
+	}
+}
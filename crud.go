@@ -0,0 +1,448 @@
+package sqly
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// fieldMeta column that struct field maps to
+type fieldMeta struct {
+	column string
+	pos    []int
+	pk     bool // primary key, tagged as `sql:"id,pk"`
+}
+
+// fields of struct to write to database, in order of declaration,
+// nested struct pointers are walked through as fieldsIterate does
+func structFieldsMeta(mType reflect.Type) []fieldMeta {
+	var metas []fieldMeta
+	var walk func(pos []int, field reflect.StructField)
+	walk = func(pos []int, field reflect.StructField) {
+		if !isExportAble(field) {
+			return
+		}
+		if !isScanAble(field) {
+			if field.Type.Kind() != reflect.Ptr || field.Type.Elem().Kind() != reflect.Struct {
+				return
+			}
+			for i := 0; i < field.Type.Elem().NumField(); i++ {
+				walk(append(append([]int{}, pos...), i), field.Type.Elem().Field(i))
+			}
+			return
+		}
+		column, opts := sqlTag(field)
+		metas = append(metas, fieldMeta{column: column, pos: pos, pk: hasTagOption(opts, "pk")})
+	}
+	for i := 0; i < mType.NumField(); i++ {
+		walk([]int{i}, mType.Field(i))
+	}
+	return metas
+}
+
+// value of struct field at pos, nil if it's in a nil nested struct
+func fieldValue(v reflect.Value, pos []int) reflect.Value {
+	for _, p := range pos {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(p)
+	}
+	return v
+}
+
+// columns and values of one row, row is a struct, struct pointer or map[string]interface{}
+func rowData(row interface{}) ([]string, []interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	switch v.Kind() {
+	case reflect.Struct:
+		metas := structFieldsMeta(v.Type())
+		cols := make([]string, 0, len(metas))
+		vals := make([]interface{}, 0, len(metas))
+		for _, m := range metas {
+			fv := fieldValue(v, m.pos)
+			// zero auto increment primary key is generated by database
+			if m.pk && isAutoIncrement(fv) {
+				continue
+			}
+			cols = append(cols, m.column)
+			if fv.IsValid() {
+				vals = append(vals, fv.Interface())
+			} else {
+				vals = append(vals, nil)
+			}
+		}
+		return cols, vals, nil
+	case reflect.Map:
+		m, ok := v.Interface().(map[string]interface{})
+		if !ok {
+			return nil, nil, ErrContainer
+		}
+		cols := make([]string, 0, len(m))
+		for k := range m {
+			cols = append(cols, k)
+		}
+		sort.Strings(cols)
+		vals := make([]interface{}, len(cols))
+		for i, col := range cols {
+			vals[i] = m[col]
+		}
+		return cols, vals, nil
+	}
+	return nil, nil, ErrContainer
+}
+
+// columns and values of rows, rows is a slice of struct, struct pointer or map[string]interface{},
+// all rows should have the same columns as the first one
+func rowsData(rows interface{}) ([]string, [][]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(rows))
+	if v.Kind() != reflect.Slice {
+		return nil, nil, ErrContainer
+	}
+	var cols []string
+	vals := make([][]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		c, val, err := rowData(v.Index(i).Interface())
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 0 {
+			cols = c
+		} else if strings.Join(c, ",") != strings.Join(cols, ",") {
+			return nil, nil, ErrFieldsMatch
+		}
+		vals = append(vals, val)
+	}
+	return cols, vals, nil
+}
+
+// pick values of columns from rows which have the columns cols
+func pickColumns(cols []string, vals [][]interface{}, want []string) ([][]interface{}, error) {
+	idx := make(map[string]int, len(cols))
+	for i, c := range cols {
+		idx[c] = i
+	}
+	rows := make([][]interface{}, 0, len(vals))
+	for _, val := range vals {
+		row := make([]interface{}, len(want))
+		for i, c := range want {
+			j, ok := idx[c]
+			if !ok {
+				return nil, ErrFieldsMatch
+			}
+			row[i] = val[j]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// insert statement with `?` placeholders of one row
+func insertFmt(driver dbDriver, table string, cols []string) string {
+	return "INSERT INTO " + quoteIdent(driver, table) + " (" + quoteIdents(driver, cols) + ") VALUES (" +
+		strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ") + ")"
+}
+
+// UpsertStatus what happened to the upserted row
+type UpsertStatus int8
+
+const (
+	// UpsertUnknown the dialect can't tell whether the row is inserted or updated
+	UpsertUnknown UpsertStatus = iota
+	// UpsertInserted the row is inserted
+	UpsertInserted
+	// UpsertUpdated the row conflicts with an existing one, which is updated
+	UpsertUpdated
+	// UpsertUnchanged the row conflicts with an existing one, which is not changed
+	UpsertUnchanged
+)
+
+// upsert clause, update all columns except the conflict ones if updateCols is empty
+func upsertClause(driver dbDriver, cols, conflictCols, updateCols []string) (string, error) {
+	if len(updateCols) == 0 {
+		conflict := make(map[string]bool)
+		for _, c := range conflictCols {
+			conflict[c] = true
+		}
+		for _, c := range cols {
+			if !conflict[c] {
+				updateCols = append(updateCols, c)
+			}
+		}
+		// nothing to update, but the conflicting row should be touched to be returned
+		if len(updateCols) == 0 && len(conflictCols) > 0 {
+			updateCols = conflictCols[:1]
+		}
+	}
+	sets := make([]string, len(updateCols))
+	switch driver {
+	case driverMysql:
+		for i, c := range updateCols {
+			sets[i] = quoteIdent(driver, c) + "=VALUES(" + quoteIdent(driver, c) + ")"
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), nil
+	case driverPostgresql, driverSqlite:
+		if len(conflictCols) == 0 {
+			return "", ErrStatement
+		}
+		for i, c := range updateCols {
+			sets[i] = quoteIdent(driver, c) + "=EXCLUDED." + quoteIdent(driver, c)
+		}
+		return " ON CONFLICT (" + quoteIdents(driver, conflictCols) + ") DO UPDATE SET " + strings.Join(sets, ", "), nil
+	default:
+		return "", ErrNotSupportForThisDriver
+	}
+}
+
+// upsert rows, which are inserted or updated on conflict of conflictCols.
+// the status of each row is reported by postgresql (with xmax system column),
+// and by mysql when there is only one row (rows affected: 1 inserted, 2 updated, 0 unchanged),
+// sqlite can't tell it, UpsertUnknown is reported
+func upsertRows(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, table string, conflictCols []string,
+	cols []string, vals [][]interface{}, updateCols []string) ([]UpsertStatus, error) {
+	if len(vals) == 0 {
+		return []UpsertStatus{}, nil
+	}
+	clause, err := upsertClause(driver, cols, conflictCols, updateCols)
+	if err != nil {
+		return nil, err
+	}
+	query, err := multiRowsFmt(driver, insertFmt(driver, table, cols)+clause, argFmt, vals)
+	if err != nil {
+		return nil, err
+	}
+	status := make([]UpsertStatus, len(vals))
+	if driver == driverPostgresql {
+		rows, err := q.QueryContext(ctx, returningFmt(driver, query, "(xmax = 0) AS inserted"))
+		if err != nil {
+			return nil, err
+		}
+		var inserted []bool
+		if err := checkAllV2(rows, &inserted); err != nil {
+			return nil, err
+		}
+		for i := range status {
+			status[i] = UpsertUpdated
+			if i < len(inserted) && inserted[i] {
+				status[i] = UpsertInserted
+			}
+		}
+		return status, nil
+	}
+	res, err := q.ExecContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if driver == driverMysql && len(vals) == 1 {
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		switch n {
+		case 1:
+			status[0] = UpsertInserted
+		case 2:
+			status[0] = UpsertUpdated
+		default:
+			status[0] = UpsertUnchanged
+		}
+	}
+	return status, nil
+}
+
+// upsert one row, row is a struct, struct pointer or map[string]interface{}
+func upsertOne(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, table string, conflictCols []string,
+	row interface{}, updateCols []string) (UpsertStatus, error) {
+	cols, vals, err := rowData(row)
+	if err != nil {
+		return UpsertUnknown, err
+	}
+	status, err := upsertRows(ctx, q, driver, argFmt, table, conflictCols, cols, [][]interface{}{vals}, updateCols)
+	if err != nil {
+		return UpsertUnknown, err
+	}
+	return status[0], nil
+}
+
+// upsert many rows, rows is a slice of struct, struct pointer or map[string]interface{}
+func upsertMany(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, table string, conflictCols []string,
+	rows interface{}, updateCols []string) ([]UpsertStatus, error) {
+	cols, vals, err := rowsData(rows)
+	if err != nil {
+		return nil, err
+	}
+	return upsertRows(ctx, q, driver, argFmt, table, conflictCols, cols, vals, updateCols)
+}
+
+// zero integer primary key, which is taken as auto increment
+func isAutoIncrement(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fv.IsZero()
+	}
+	return false
+}
+
+// set generated id to auto increment primary key
+func setAutoIncrement(fv reflect.Value, id int64) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fv.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fv.SetUint(uint64(id))
+	}
+}
+
+// row should be a struct pointer, not nil
+func structPtrValue(row interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(row)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, ErrContainer
+	}
+	return v, nil
+}
+
+// where clause of primary keys
+func pkWhere(driver dbDriver, v reflect.Value, metas []fieldMeta) (string, []interface{}, error) {
+	var conds []string
+	var args []interface{}
+	for _, m := range metas {
+		if !m.pk {
+			continue
+		}
+		fv := fieldValue(v, m.pos)
+		if !fv.IsValid() {
+			return "", nil, ErrNoPrimaryKey
+		}
+		conds = append(conds, quoteIdent(driver, m.column)+"=?")
+		args = append(args, fv.Interface())
+	}
+	if len(conds) == 0 {
+		return "", nil, ErrNoPrimaryKey
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// insert struct, the generated id is set to the zero auto increment primary key
+func insertStruct(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, table string, row interface{}) (*Affected, error) {
+	v, err := structPtrValue(row)
+	if err != nil {
+		return nil, err
+	}
+	var cols []string
+	var vals []interface{}
+	var autoField reflect.Value
+	var autoCol string
+	for _, m := range structFieldsMeta(v.Elem().Type()) {
+		fv := fieldValue(v, m.pos)
+		if m.pk && isAutoIncrement(fv) {
+			autoField, autoCol = fv, m.column
+			continue
+		}
+		cols = append(cols, m.column)
+		if fv.IsValid() {
+			vals = append(vals, fv.Interface())
+		} else {
+			vals = append(vals, nil)
+		}
+	}
+	if len(cols) == 0 {
+		return nil, ErrStatement
+	}
+	query, err := statementFormat(insertFmt(driver, table, cols), argFmt, vals...)
+	if err != nil {
+		return nil, err
+	}
+	if !autoField.IsValid() {
+		res, err := q.ExecContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return &Affected{result: res, driver: driver}, nil
+	}
+	aff := &Affected{driver: driver}
+	switch driver {
+	case driverPostgresql, driverMssql:
+		// no LastInsertId, the id is returned by RETURNING (OUTPUT) clause
+		err = q.QueryRowContext(ctx, returningFmt(driver, query, quoteIdent(driver, autoCol))).Scan(&aff.lastId)
+		if err != nil {
+			return nil, err
+		}
+		aff.rowsAffected = 1
+	default:
+		aff.result, err = q.ExecContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if aff.lastId, err = aff.result.LastInsertId(); err != nil {
+			return nil, err
+		}
+	}
+	setAutoIncrement(autoField, aff.lastId)
+	return aff, nil
+}
+
+// update all columns but primary keys of struct, keyed by primary keys
+func updateStruct(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, table string, row interface{}) (*Affected, error) {
+	v, err := structPtrValue(row)
+	if err != nil {
+		return nil, err
+	}
+	metas := structFieldsMeta(v.Elem().Type())
+	var sets []string
+	var args []interface{}
+	for _, m := range metas {
+		if m.pk {
+			continue
+		}
+		sets = append(sets, quoteIdent(driver, m.column)+"=?")
+		if fv := fieldValue(v, m.pos); fv.IsValid() {
+			args = append(args, fv.Interface())
+		} else {
+			args = append(args, nil)
+		}
+	}
+	if len(sets) == 0 {
+		return nil, ErrStatement
+	}
+	where, pkArgs, err := pkWhere(driver, v, metas)
+	if err != nil {
+		return nil, err
+	}
+	query, err := statementFormat("UPDATE "+quoteIdent(driver, table)+" SET "+strings.Join(sets, ", ")+where,
+		argFmt, append(args, pkArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := q.ExecContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &Affected{result: res, driver: driver}, nil
+}
+
+// delete the row of struct, keyed by primary keys
+func deleteByPK(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, table string, row interface{}) (*Affected, error) {
+	v, err := structPtrValue(row)
+	if err != nil {
+		return nil, err
+	}
+	where, args, err := pkWhere(driver, v, structFieldsMeta(v.Elem().Type()))
+	if err != nil {
+		return nil, err
+	}
+	query, err := statementFormat("DELETE FROM "+quoteIdent(driver, table)+where, argFmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := q.ExecContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &Affected{result: res, driver: driver}, nil
+}
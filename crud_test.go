@@ -0,0 +1,97 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestMysql_Upsert(t *testing.T) {
+	var aff int64
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{aff: aff}, nil
+	})
+	row := map[string]interface{}{"mobile": "18812311231", "nickname": "lucy", "role": 1}
+	for n, want := range map[int64]UpsertStatus{1: UpsertInserted, 2: UpsertUpdated, 0: UpsertUnchanged} {
+		aff = n
+		status, err := db.Upsert(context.TODO(), "account", []string{"mobile"}, row)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != want {
+			t.Errorf("rows affected %d, got status %d", n, status)
+		}
+	}
+	qs := fakeMysql.recorded()
+	cmp := "INSERT INTO `account` (`mobile`, `nickname`, `role`) VALUES ('18812311231', 'lucy', 1) " +
+		"ON DUPLICATE KEY UPDATE `nickname`=VALUES(`nickname`), `role`=VALUES(`role`);"
+	if qs[0] != cmp {
+		t.Errorf("got %s", qs[0])
+	}
+
+	_, err := db.UpsertMany(context.TODO(), "account", []string{"mobile"}, []map[string]interface{}{
+		row, {"mobile": "18812311232", "nickname": "lily", "role": 2},
+	}, "nickname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs = fakeMysql.recorded()
+	cmp = "INSERT INTO `account` (`mobile`, `nickname`, `role`) VALUES ('18812311231', 'lucy', 1)," +
+		"('18812311232', 'lily', 2) ON DUPLICATE KEY UPDATE `nickname`=VALUES(`nickname`);"
+	if qs[len(qs)-1] != cmp {
+		t.Errorf("got %s", qs[len(qs)-1])
+	}
+}
+
+func TestPostgres_UpsertMany(t *testing.T) {
+	db := newFakeDb(t, fakePostgres, "fake_postgres", driverPostgresql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{
+			columns: []fakeColumn{{name: "inserted", typeName: "BOOL"}},
+			rows:    [][]driver.Value{{true}, {false}},
+		}, nil
+	})
+	type account struct {
+		Mobile   string `sql:"mobile"`
+		Nickname string `sql:"nickname"`
+	}
+	status, err := db.UpsertMany(context.TODO(), "public.account", []string{"mobile"}, []*account{
+		{Mobile: "18812311231", Nickname: "lucy"}, {Mobile: "18812311232", Nickname: "lily"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 || status[0] != UpsertInserted || status[1] != UpsertUpdated {
+		t.Errorf("got status %v", status)
+	}
+	qs := fakePostgres.recorded()
+	cmp := `INSERT INTO "public"."account" ("mobile", "nickname") VALUES (E'18812311231', E'lucy'),(E'18812311232', E'lily') ` +
+		`ON CONFLICT ("mobile") DO UPDATE SET "nickname"=EXCLUDED."nickname" RETURNING (xmax = 0) AS inserted`
+	if qs[0] != cmp {
+		t.Errorf("got %s", qs[0])
+	}
+}
+
+func TestPostgres_InsertStruct(t *testing.T) {
+	db := newFakeDb(t, fakePostgres, "fake_postgres", driverPostgresql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{
+			columns: []fakeColumn{{name: "id", typeName: "INT8"}},
+			rows:    [][]driver.Value{{int64(7)}},
+		}, nil
+	})
+	type account struct {
+		ID       uint32 `sql:"id,pk"`
+		Nickname string `sql:"nickname"`
+	}
+	acc := &account{Nickname: "lucy"}
+	aff, err := db.InsertStruct(context.TODO(), "account", acc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := aff.GetLastId(); id != 7 || acc.ID != 7 {
+		t.Errorf("got id %d, struct id %d", id, acc.ID)
+	}
+	qs := fakePostgres.recorded()
+	if qs[0] != `INSERT INTO "account" ("nickname") VALUES (E'lucy') RETURNING "id"` {
+		t.Errorf("got %s", qs[0])
+	}
+}
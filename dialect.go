@@ -0,0 +1,203 @@
+package sqly
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// executor *sql.DB, *sql.Tx and *sql.Conn
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txBeginner *sql.DB and *sql.Conn
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// serverInfo features of database server, which are detected on demand
+type serverInfo struct {
+	mu       sync.Mutex
+	detected bool
+	mariadb  bool
+}
+
+// isMariadb whether the mysql server is mariadb
+func (i *serverInfo) isMariadb(ctx context.Context, q executor) (bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.detected {
+		return i.mariadb, nil
+	}
+	var version string
+	if err := q.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return false, err
+	}
+	i.mariadb = strings.Contains(strings.ToLower(version), "mariadb")
+	i.detected = true
+	return i.mariadb, nil
+}
+
+// supportReturning whether the database supports RETURNING clause,
+// mariadb supports it since 10.5, mysql does not
+func (i *serverInfo) supportReturning(ctx context.Context, q executor, driver dbDriver) (bool, error) {
+	switch driver {
+	case driverPostgresql, driverSqlite:
+		return true, nil
+	case driverMysql:
+		return i.isMariadb(ctx, q)
+	default:
+		return false, nil
+	}
+}
+
+// bindVar placeholder of the nth(start from 1) bind argument
+func bindVar(driver dbDriver, n int) string {
+	switch driver {
+	case driverPostgresql:
+		return "$" + strconv.Itoa(n)
+	case driverMssql:
+		return "@p" + strconv.Itoa(n)
+	default:
+		return "?"
+	}
+}
+
+// rebind replace the `?` placeholders to the bind style of driver,
+// placeholders in quoted strings and identifiers are ignored
+func rebind(driver dbDriver, query string) string {
+	if driver != driverPostgresql && driver != driverMssql {
+		return query
+	}
+	var buff bytes.Buffer
+	n := 0
+	for _, t := range tokenize(query, driver) {
+		if t.kind == tokenPlaceholder {
+			n++
+			buff.WriteString(bindVar(driver, n))
+			continue
+		}
+		buff.WriteString(t.text)
+	}
+	return buff.String()
+}
+
+// Rebind replace the `?` placeholders of query to the bind style of current driver,
+// such as $1 for postgresql and @p1 for sql server, so that the query can be
+// executed with bind arguments through DB()
+func (s *SqlY) Rebind(query string) string {
+	return rebind(s.driver, query)
+}
+
+// indexKeyword index of the first top level keyword (case insensitive, words separated by spaces) in query,
+// keywords in strings, comments, quoted identifiers and parentheses (such as subqueries) are skipped
+func indexKeyword(driver dbDriver, query, keyword string) int {
+	words := strings.Fields(keyword)
+	tokens := tokenize(query, driver)
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+		case depth == 0 && t.is(words[0]):
+			j, n := i, 1
+			for ; n < len(words); n++ {
+				if j = nextSignificant(tokens, j+1); j < 0 || !tokens[j].is(words[n]) {
+					break
+				}
+			}
+			if n == len(words) {
+				return t.pos
+			}
+		}
+	}
+	return -1
+}
+
+// returningFmt append the clause which returns field of the modified rows,
+// sql server uses `OUTPUT INSERTED.field` in place of `RETURNING field`
+func returningFmt(driver dbDriver, query, field string) string {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if driver != driverMssql {
+		return fmt.Sprintf("%s RETURNING %s", query, field)
+	}
+	output := " OUTPUT INSERTED." + field + " "
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "DELETE") {
+		output = " OUTPUT DELETED." + field + " "
+	}
+	// OUTPUT clause is placed before VALUES/SELECT of insert, WHERE of update and delete
+	for _, kw := range []string{"DEFAULT VALUES", "VALUES", "SELECT", "WHERE"} {
+		if i := indexKeyword(driver, query, kw); i > 0 {
+			return strings.TrimRight(query[:i], " ") + output + query[i:]
+		}
+	}
+	return query + output
+}
+
+// returningAllFmt append `RETURNING *` if there is no RETURNING clause in query
+func returningAllFmt(driver dbDriver, query string) string {
+	if indexKeyword(driver, query, "RETURNING") >= 0 {
+		return query
+	}
+	return returningFmt(driver, query, "*")
+}
+
+// limitFmt append the pagination clause to query,
+// sql server uses `OFFSET n ROWS FETCH NEXT m ROWS ONLY` (ORDER BY is required),
+// or `TOP m` when there is no offset
+func limitFmt(driver dbDriver, query string, limit, offset int64) string {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if driver != driverMssql {
+		if offset > 0 {
+			return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+		}
+		return fmt.Sprintf("%s LIMIT %d", query, limit)
+	}
+	if offset <= 0 && indexKeyword(driver, query, "ORDER BY") < 0 {
+		if i := indexKeyword(driver, query, "SELECT"); i >= 0 {
+			i += len("SELECT")
+			if j := indexKeyword(driver, query[i:], "DISTINCT"); j >= 0 && strings.TrimSpace(query[i:i+j]) == "" {
+				i += j + len("DISTINCT")
+			}
+			return fmt.Sprintf("%s TOP %d%s", query[:i], limit, query[i:])
+		}
+	}
+	if indexKeyword(driver, query, "ORDER BY") < 0 {
+		query += " ORDER BY (SELECT NULL)"
+	}
+	return fmt.Sprintf("%s OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", query, offset, limit)
+}
+
+// quoteIdent quote identifier such as table and column name, schema.table is quoted separately
+func quoteIdent(driver dbDriver, name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		switch driver {
+		case driverMysql, driverClickhouse, driverOthers:
+			parts[i] = "`" + strings.Replace(p, "`", "``", -1) + "`"
+		case driverMssql:
+			parts[i] = "[" + strings.Replace(p, "]", "]]", -1) + "]"
+		default:
+			parts[i] = `"` + strings.Replace(p, `"`, `""`, -1) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteIdents quote identifiers and join them with comma
+func quoteIdents(driver dbDriver, names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(driver, name)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -32,4 +32,25 @@ var (
 
 	// ErrNotSupportForThisDriver driver not support
 	ErrNotSupportForThisDriver = errors.New("not support for this driver")
+
+	// ErrIdsNotConsecutive auto increment ids of multi rows insert are not consecutive
+	ErrIdsNotConsecutive = errors.New("auto increment ids of inserted rows are not consecutive")
+
+	// ErrNoPrimaryKey no field of struct is tagged as primary key
+	ErrNoPrimaryKey = errors.New("no primary key field in struct (tagged as `sql:\"id,pk\"`)")
+
+	// ErrNotTracked row is not tracked by Track
+	ErrNotTracked = errors.New("row is not tracked, call Track first")
+
+	// ErrNoWhere update or delete without where clause, call All to modify all rows
+	ErrNoWhere = errors.New("no where clause for update or delete, call All() to modify all rows")
+
+	// ErrInvalidCursor cursor of pagination can't be decoded
+	ErrInvalidCursor = errors.New("invalid page cursor")
+
+	// ErrNoReaderHandler reader handler of mysql driver is not registered for LoadData
+	ErrNoReaderHandler = errors.New("reader handler is not registered, import github.com/FeifeiyuM/sqly/mysqlreader")
+
+	// ErrNoTransaction transaction scoped operation out of transaction
+	ErrNoTransaction = errors.New("transaction is required, capsule is not in transaction")
 )
@@ -0,0 +1,248 @@
+package sqly
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fake database/sql driver, it records the statements it received,
+// and answers queries with the rows given by the handler of test
+
+type fakeColumn struct {
+	name     string
+	typeName string
+	nullable bool
+	scanType reflect.Type // interface{} if it's nil
+}
+
+type fakeResult struct {
+	columns []fakeColumn
+	rows    [][]driver.Value
+	lastId  int64
+	aff     int64
+}
+
+type fakeHandler func(query string, args []driver.NamedValue) (*fakeResult, error)
+
+type fakeDriver struct {
+	mu      sync.Mutex
+	queries []string
+	handler fakeHandler
+	ping    func(ctx context.Context) error // answer of ping, nil means ok
+	opened  int                             // number of opened connections
+	closed  int                             // number of closed connections
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	d.opened++
+	d.mu.Unlock()
+	return &fakeConn{d: d}, nil
+}
+
+// record query and get the answer
+func (d *fakeDriver) answer(query string, args []driver.NamedValue) (*fakeResult, error) {
+	d.mu.Lock()
+	d.queries = append(d.queries, query)
+	handler := d.handler
+	d.mu.Unlock()
+	if handler == nil {
+		return &fakeResult{}, nil
+	}
+	return handler(query, args)
+}
+
+// reset clear records and set handler
+func (d *fakeDriver) reset(handler fakeHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = nil
+	d.handler = handler
+}
+
+// recorded statements
+func (d *fakeDriver) recorded() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.queries...)
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+
+// CheckNamedValue accept any argument, as clickhouse driver accepts slices
+func (c *fakeConn) CheckNamedValue(v *driver.NamedValue) error {
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.d.mu.Lock()
+	c.d.closed++
+	c.d.mu.Unlock()
+	return nil
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	c.d.mu.Lock()
+	ping := c.d.ping
+	c.d.mu.Unlock()
+	if ping == nil {
+		return nil
+	}
+	return ping(ctx)
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.d.answer("BEGIN", nil)
+	return &fakeTx{c: c}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := c.d.answer(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := c.d.answer(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{res: res}, nil
+}
+
+type fakeTx struct {
+	c *fakeConn
+}
+
+func (t *fakeTx) Commit() error {
+	t.c.d.answer("COMMIT", nil)
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.c.d.answer("ROLLBACK", nil)
+	return nil
+}
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("fake driver: use ExecContext")
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("fake driver: use QueryContext")
+}
+
+func (s *fakeStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.ExecContext(ctx, s.query, args)
+}
+
+func (s *fakeStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.QueryContext(ctx, s.query, args)
+}
+
+func (r *fakeResult) LastInsertId() (int64, error) {
+	return r.lastId, nil
+}
+
+func (r *fakeResult) RowsAffected() (int64, error) {
+	return r.aff, nil
+}
+
+type fakeRows struct {
+	res *fakeResult
+	pos int
+}
+
+func (r *fakeRows) Columns() []string {
+	var cols []string
+	for _, c := range r.res.columns {
+		cols = append(cols, c.name)
+	}
+	return cols
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.res.rows) {
+		return io.EOF
+	}
+	copy(dest, r.res.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.res.columns[index].typeName
+}
+
+func (r *fakeRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.res.columns[index].nullable, true
+}
+
+func (r *fakeRows) ColumnTypeScanType(index int) reflect.Type {
+	if t := r.res.columns[index].scanType; t != nil {
+		return t
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+// registered fake drivers, the name of driver decides the dialect
+var (
+	fakeMssql      = &fakeDriver{}
+	fakeClickhouse = &fakeDriver{}
+	// dialect of mysql and postgresql, as the names are taken by the real drivers
+	fakeMysql    = &fakeDriver{}
+	fakePostgres = &fakeDriver{}
+	// driver of ping tests, without dialect
+	fakePinger = &fakeDriver{}
+)
+
+func init() {
+	sql.Register("sqlserver", fakeMssql)
+	sql.Register("clickhouse", fakeClickhouse)
+	sql.Register("fake_mysql", fakeMysql)
+	sql.Register("fake_postgres", fakePostgres)
+	sql.Register("fake_ping", fakePinger)
+}
+
+// open a fake database with the dialect of driver
+func newFakeDb(t *testing.T, d *fakeDriver, driverName string, driver dbDriver, handler fakeHandler) *SqlY {
+	d.reset(handler)
+	db, err := sql.Open(driverName, "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialects := map[dbDriver]string{driverMysql: "mysql", driverPostgresql: "postgres",
+		driverMssql: "sqlserver", driverClickhouse: "clickhouse"}
+	return NewFromDB(db, dialects[driver])
+}
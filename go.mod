@@ -1,8 +1,9 @@
 module github.com/FeifeiyuM/sqly
 
-go 1.13
+go 1.16
 
 require (
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/lib/pq v1.10.1
+	github.com/mattn/go-sqlite3 v1.14.22
 )
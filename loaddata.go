@@ -0,0 +1,110 @@
+package sqly
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sync/atomic"
+)
+
+// sequence of reader handler names, each LoadData registers its own handler
+var loadDataSeq uint64
+
+// reader handler functions of mysql driver, set by RegisterReaderHandler
+var readerHandler struct {
+	register   func(name string, handler func() io.Reader)
+	deregister func(name string)
+}
+
+// RegisterReaderHandler set the functions registering reader handler of LOAD DATA LOCAL INFILE 'Reader::<name>',
+// which are mysql.RegisterReaderHandler and mysql.DeregisterReaderHandler of go-sql-driver/mysql,
+// it's called by importing github.com/FeifeiyuM/sqly/mysqlreader, so that sqly does not depend on the driver
+func RegisterReaderHandler(register func(name string, handler func() io.Reader), deregister func(name string)) {
+	readerHandler.register = register
+	readerHandler.deregister = deregister
+}
+
+// write rows as LOAD DATA input, fields are formatted as mysqlArgFormat does,
+// strings are enclosed by `'` and escaped by `\`, NULL is the unquoted word NULL
+func writeLoadData(w io.Writer, columns []string, src RowSource) error {
+	bw := bufio.NewWriter(w)
+	for src.Next() {
+		vals, err := src.Values()
+		if err != nil {
+			return err
+		}
+		if len(vals) != len(columns) {
+			return ErrFieldsMatch
+		}
+		for i, val := range vals {
+			if val != nil {
+				if t := reflect.TypeOf(val); (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) &&
+					t.Elem().Kind() != reflect.Uint8 {
+					return ErrArgType
+				}
+			}
+			field, err := mysqlArgFormat(",", val)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				_ = bw.WriteByte(',')
+			}
+			_, _ = bw.WriteString(field)
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if err := src.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// load rows of source into table with LOAD DATA LOCAL INFILE, rows are streamed by reader handler of mysql driver
+func loadData(ctx context.Context, q executor, driver dbDriver, table string, columns []string,
+	source interface{}) (*Affected, error) {
+	if driver != driverMysql {
+		return nil, ErrNotSupportForThisDriver
+	}
+	if readerHandler.register == nil {
+		return nil, ErrNoReaderHandler
+	}
+	columns, src, err := rowSource(columns, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, ErrFieldsMatch
+	}
+	pr, pw := io.Pipe()
+	name := fmt.Sprintf("sqly_load_data_%d", atomic.AddUint64(&loadDataSeq, 1))
+	readerHandler.register(name, func() io.Reader {
+		return pr
+	})
+	defer readerHandler.deregister(name)
+
+	errCh := make(chan error, 1)
+	go func() {
+		err := writeLoadData(pw, columns, src)
+		_ = pw.CloseWithError(err)
+		errCh <- err
+	}()
+	query := "LOAD DATA LOCAL INFILE 'Reader::" + name + "' INTO TABLE " + quoteIdent(driver, table) +
+		" CHARACTER SET utf8mb4 FIELDS TERMINATED BY ',' ENCLOSED BY '\\'' ESCAPED BY '\\\\'" +
+		" LINES TERMINATED BY '\\n' (" + quoteIdents(driver, columns) + ")"
+	res, err := q.ExecContext(ctx, query)
+	// stop writing if the data is not read
+	_ = pr.Close()
+	werr := <-errCh
+	if err != nil {
+		return nil, err
+	}
+	if werr != nil && werr != io.ErrClosedPipe {
+		return nil, werr
+	}
+	return &Affected{result: res, driver: driver}, nil
+}
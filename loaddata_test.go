@@ -0,0 +1,58 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMysql_LoadData(t *testing.T) {
+	var buf strings.Builder
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := [][]interface{}{
+		{"it's \"lucy\"\n", nil, true, at},
+		{"lily,a\\b", NullInt32{Int32: 2, Valid: true}, NullBool{}, NullTime{}},
+	}
+	if err := writeLoadData(&buf, []string{"nickname", "role", "is_valid", "add_time"}, &sliceSource{rows: rows}); err != nil {
+		t.Fatal(err)
+	}
+	cmp := "'it\\'s \"lucy\"\\n',NULL,1,'2020-01-02 03:04:05.000000000'\n" +
+		"'lily,a\\\\b',2,NULL,NULL\n"
+	if buf.String() != cmp {
+		t.Errorf("got %s", buf.String())
+	}
+
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{aff: 2}, nil
+	})
+	if _, err := db.LoadData(context.TODO(), "account", []string{"nickname"}, rows); err != ErrNoReaderHandler {
+		t.Errorf("expect ErrNoReaderHandler, got %v", err)
+	}
+	handlers := make(map[string]func() io.Reader)
+	RegisterReaderHandler(func(name string, handler func() io.Reader) {
+		handlers[name] = handler
+	}, func(name string) {
+		delete(handlers, name)
+	})
+	defer RegisterReaderHandler(nil, nil)
+
+	aff, err := db.LoadData(context.TODO(), "account", []string{"nickname", "role", "is_valid", "add_time"}, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := aff.GetRowsAffected(); n != 2 {
+		t.Errorf("rows affected %d", n)
+	}
+	qs := fakeMysql.recorded()
+	if !strings.HasPrefix(qs[0], "LOAD DATA LOCAL INFILE 'Reader::sqly_load_data_") ||
+		!strings.HasSuffix(qs[0], "INTO TABLE `account` CHARACTER SET utf8mb4 FIELDS TERMINATED BY ',' ENCLOSED BY '\\'' "+
+			"ESCAPED BY '\\\\' LINES TERMINATED BY '\\n' (`nickname`, `role`, `is_valid`, `add_time`)") {
+		t.Errorf("got %s", qs[0])
+	}
+	if len(handlers) != 0 {
+		t.Errorf("reader handler is not deregistered")
+	}
+}
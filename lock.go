@@ -0,0 +1,148 @@
+package sqly
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// key of postgresql advisory lock, hashed from name
+func pgLockKey(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return int64(h.Sum64())
+}
+
+// name of mysql user lock, which is at most 64 characters
+func mysqlLockName(key string) string {
+	if len(key) <= 64 {
+		return key
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return fmt.Sprintf("%s_%016x", key[:47], h.Sum64())
+}
+
+// name of sql server application lock, which is at most 255 characters
+func mssqlLockName(key string) string {
+	if len(key) <= 255 {
+		return key
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return fmt.Sprintf("%s_%016x", key[:238], h.Sum64())
+}
+
+// acquire session lock, wait until it's acquired if wait is true
+func acquireLock(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, key string, wait bool) (bool, error) {
+	var query string
+	var arg interface{}
+	switch driver {
+	case driverPostgresql:
+		query, arg = "SELECT pg_try_advisory_lock(?)", pgLockKey(key)
+		if wait {
+			query = "SELECT true FROM pg_advisory_lock(?)"
+		}
+	case driverMysql:
+		// GET_LOCK returns 1 if acquired, 0 if timeout, and NULL on error
+		query, arg = "SELECT COALESCE(GET_LOCK(?, 0), 0) = 1", mysqlLockName(key)
+		if wait {
+			query = "SELECT COALESCE(GET_LOCK(?, -1), 0) = 1"
+		}
+	case driverMssql:
+		// sp_getapplock returns 0 or 1 if acquired, negative values on timeout or error
+		query, arg = "DECLARE @r INT; EXEC @r = sp_getapplock @Resource = ?, @LockMode = 'Exclusive', "+
+			"@LockOwner = 'Session', @LockTimeout = 0; SELECT CASE WHEN @r >= 0 THEN 1 ELSE 0 END", mssqlLockName(key)
+		if wait {
+			query = strings.Replace(query, "@LockTimeout = 0", "@LockTimeout = -1", 1)
+		}
+	default:
+		return false, ErrNotSupportForThisDriver
+	}
+	query, err := statementFormat(query, argFmt, arg)
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	if err := q.QueryRowContext(ctx, query).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// release session lock
+func releaseLock(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, key string) error {
+	var query string
+	var arg interface{}
+	switch driver {
+	case driverPostgresql:
+		query, arg = "SELECT pg_advisory_unlock(?)", pgLockKey(key)
+	case driverMysql:
+		query, arg = "SELECT RELEASE_LOCK(?)", mysqlLockName(key)
+	case driverMssql:
+		query, arg = "EXEC sp_releaseapplock @Resource = ?, @LockOwner = 'Session'", mssqlLockName(key)
+	default:
+		return ErrNotSupportForThisDriver
+	}
+	query, err := statementFormat(query, argFmt, arg)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, query)
+	return err
+}
+
+// run fn holding session lock of key on a pinned connection
+func withLock(ctx context.Context, db *sql.DB, driver dbDriver, argFmt argFormat, key string, wait bool,
+	fn func(ctx context.Context) error) (ok bool, err error) {
+	if driver != driverPostgresql && driver != driverMysql && driver != driverMssql {
+		return false, ErrNotSupportForThisDriver
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if ok, err = acquireLock(ctx, conn, driver, argFmt, key, wait); err != nil || !ok {
+		if err != nil {
+			// the lock may be granted though the query failed, such as ctx is cancelled
+			discardConn(conn)
+		}
+		return false, err
+	}
+	// release before the connection is returned to pool, even if ctx is cancelled or fn panics,
+	// the connection still holding the lock is discarded if it fails to release
+	defer func() {
+		if rErr := releaseLock(context.Background(), conn, driver, argFmt, key); rErr != nil {
+			discardConn(conn)
+			if err == nil {
+				err = rErr
+			}
+		}
+	}()
+	return true, fn(ctx)
+}
+
+// acquire lock released at the end of transaction, only postgresql supports it
+func acquireXactLock(ctx context.Context, tx *sql.Tx, driver dbDriver, argFmt argFormat, key string, wait bool) (bool, error) {
+	if driver != driverPostgresql {
+		return false, ErrNotSupportForThisDriver
+	}
+	query := "SELECT pg_try_advisory_xact_lock(?)"
+	if wait {
+		query = "SELECT true FROM pg_advisory_xact_lock(?)"
+	}
+	query, err := statementFormat(query, argFmt, pgLockKey(key))
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	if err := tx.QueryRowContext(ctx, query).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
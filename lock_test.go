@@ -0,0 +1,123 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMysql_WithLock(t *testing.T) {
+	locked := false
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if strings.Contains(query, "GET_LOCK") {
+			res := &fakeResult{columns: []fakeColumn{{name: "ok", typeName: "BIGINT"}}, rows: [][]driver.Value{{int64(1)}}}
+			if locked {
+				res.rows[0][0] = int64(0)
+			}
+			return res, nil
+		}
+		return &fakeResult{}, nil
+	})
+	ctx := context.TODO()
+	errFn := errors.New("job failed")
+	err := db.WithLock(ctx, "cron:report", func(ctx context.Context) error {
+		return errFn
+	})
+	if err != errFn {
+		t.Errorf("expect error of fn, got %v", err)
+	}
+	qs := fakeMysql.recorded()
+	cmp := []string{"SELECT COALESCE(GET_LOCK('cron:report', -1), 0) = 1", "SELECT RELEASE_LOCK('cron:report')"}
+	if len(qs) != 2 || qs[0] != cmp[0] || qs[1] != cmp[1] {
+		t.Errorf("got %v", qs)
+	}
+
+	locked = true
+	called := false
+	ok, err := db.TryLock(ctx, "cron:report", func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	if err != nil || ok || called {
+		t.Errorf("lock held by others, got %v %v %v", ok, err, called)
+	}
+
+	_, err = db.Transaction(func(tx *Trans) (interface{}, error) {
+		return nil, tx.Lock(ctx, "cron:report")
+	})
+	if err != ErrNotSupportForThisDriver {
+		t.Errorf("expect ErrNotSupportForThisDriver, got %v", err)
+	}
+	if err := NewCapsule(db).Lock(ctx, "cron:report"); err != ErrNoTransaction {
+		t.Errorf("expect ErrNoTransaction, got %v", err)
+	}
+}
+
+// the connection holding the lock is not returned to pool
+func TestMysql_WithLockDiscard(t *testing.T) {
+	failed := "RELEASE_LOCK"
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if strings.Contains(query, failed) {
+			return nil, errors.New("connection lost")
+		}
+		return &fakeResult{columns: []fakeColumn{{name: "ok", typeName: "BIGINT"}}, rows: [][]driver.Value{{int64(1)}}}, nil
+	})
+	defer db.Close()
+	ctx := context.TODO()
+	for _, f := range []string{"RELEASE_LOCK", "GET_LOCK"} {
+		failed = f
+		err := db.WithLock(ctx, "cron:report", func(ctx context.Context) error {
+			return nil
+		})
+		if err == nil || err.Error() != "connection lost" {
+			t.Errorf("expect error of %s, got %v", f, err)
+		}
+		if stats := db.Stats(); stats.OpenConnections != 0 || stats.Idle != 0 {
+			t.Errorf("%s failed, unexpected stats %+v", f, stats)
+		}
+	}
+}
+
+func TestPostgres_XactLock(t *testing.T) {
+	db := newFakeDb(t, fakePostgres, "fake_postgres", driverPostgresql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{columns: []fakeColumn{{name: "ok", typeName: "BOOL"}}, rows: [][]driver.Value{{true}}}, nil
+	})
+	ctx := context.TODO()
+	capsule := NewCapsule(db)
+	_, err := capsule.StartCapsule(ctx, true, func(ctx context.Context) (interface{}, error) {
+		if err := capsule.Lock(ctx, "job"); err != nil {
+			return nil, err
+		}
+		return capsule.TryLock(ctx, "job")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := pgLockKey("job")
+	qs := fakePostgres.recorded()
+	if len(qs) != 4 || qs[1] != "SELECT true FROM pg_advisory_xact_lock("+strconv.FormatInt(key, 10)+")" ||
+		qs[2] != "SELECT pg_try_advisory_xact_lock("+strconv.FormatInt(key, 10)+")" || qs[3] != "COMMIT" {
+		t.Errorf("got %v", qs)
+	}
+}
+
+func TestMssql_WithLock(t *testing.T) {
+	db := newFakeDb(t, fakeMssql, "sqlserver", driverMssql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{columns: []fakeColumn{{name: "ok", typeName: "INT"}}, rows: [][]driver.Value{{int64(1)}}}, nil
+	})
+	err := db.WithLock(context.TODO(), "sqly_migrate", func(ctx context.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := fakeMssql.recorded()
+	if len(qs) != 2 || !strings.Contains(qs[0], "sp_getapplock @Resource = N'sqly_migrate'") ||
+		!strings.Contains(qs[0], "@LockTimeout = -1") ||
+		qs[1] != "EXEC sp_releaseapplock @Resource = N'sqly_migrate', @LockOwner = 'Session'" {
+		t.Errorf("got %v", qs)
+	}
+}
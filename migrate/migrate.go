@@ -0,0 +1,160 @@
+// Package migrate applies versioned sql migrations with sqly.
+//
+// Migrations are read from a fs.FS (os.DirFS, embed.FS), named as <version>_<name>.up.sql
+// and <version>_<name>.down.sql, such as 20240101120000_create_account.up.sql.
+// Applied versions are recorded with the checksum of up migration in the schema table.
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/FeifeiyuM/sqly"
+)
+
+var (
+	// ErrChecksumMismatch applied migration is modified
+	ErrChecksumMismatch = errors.New("checksum of applied migration mismatch")
+
+	// ErrNoDown down migration is not found
+	ErrNoDown = errors.New("down migration not found")
+
+	// ErrNoApplied no migration is applied
+	ErrNoApplied = errors.New("no migration applied")
+
+	// ErrDuplicateVersion more than one migration of the same version
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+)
+
+// DefaultTable default name of schema table
+const DefaultTable = "sqly_migrations"
+
+// Options options of migrator
+type Options struct {
+	Dir   string // directory of migrations in fs, default "."
+	Table string // schema table recording applied versions, default sqly_migrations
+}
+
+// Migration versioned migration
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string // empty if there is no down migration
+	Checksum string // sha256 of up migration
+}
+
+// Status status of migration
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time // zero if it's not applied
+	Modified  bool      // up migration is modified after it's applied
+	Missing   bool      // applied, but the migration file is missing
+}
+
+// applied version in schema table
+type record struct {
+	Version   int64     `sql:"version"`
+	Name      string    `sql:"name"`
+	Checksum  string    `sql:"checksum"`
+	AppliedAt time.Time `sql:"applied_at"`
+}
+
+// Migrator apply migrations to database
+type Migrator struct {
+	db         *sqly.SqlY
+	table      string
+	migrations []*Migration
+}
+
+var fileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load read migrations in dir of fsys, sorted by version
+func Load(fsys fs.FS, dir string) ([]*Migration, error) {
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		match := fileRegexp.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		b, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d: %w", version, ErrDuplicateVersion)
+		}
+		if match[3] == "up" {
+			if m.Checksum != "" {
+				return nil, fmt.Errorf("migration %d: %w", version, ErrDuplicateVersion)
+			}
+			m.Up = string(b)
+			sum := sha256.Sum256(b)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(b)
+		}
+	}
+	var migrations []*Migration
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %d: up migration not found", m.Version)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// New init migrator of migrations in fsys, opts can be nil
+func New(db *sqly.SqlY, fsys fs.FS, opts *Options) (*Migrator, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	migrations, err := Load(fsys, opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	table := opts.Table
+	if table == "" {
+		table = DefaultTable
+	}
+	switch db.DriverName() {
+	case "mysql", "postgres", "sqlite3", "sqlserver":
+	default:
+		return nil, sqly.ErrNotSupportForThisDriver
+	}
+	return &Migrator{db: db, table: table, migrations: migrations}, nil
+}
+
+// Migrations loaded migrations, sorted by version
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
@@ -0,0 +1,137 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/FeifeiyuM/sqly"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var testFS = fstest.MapFS{
+	"migrations/0001_create_account.up.sql": {Data: []byte(
+		"CREATE TABLE account (id INTEGER PRIMARY KEY, nickname VARCHAR(32) NOT NULL);\n" +
+			"CREATE INDEX idx_nickname ON account (nickname);\n")},
+	"migrations/0001_create_account.down.sql": {Data: []byte("DROP TABLE account;\n")},
+	"migrations/0002_add_mobile.up.sql":       {Data: []byte("ALTER TABLE account ADD COLUMN mobile VARCHAR(16);\n")},
+	"migrations/0002_add_mobile.down.sql":     {Data: []byte("ALTER TABLE account DROP COLUMN mobile;\n")},
+	"migrations/0003_seed.up.sql":             {Data: []byte("INSERT INTO account (nickname, mobile) VALUES ('a;b', '1');\n")},
+	"migrations/README.md":                    {Data: []byte("not a migration")},
+}
+
+func newSqliteDb(t *testing.T) *sqly.SqlY {
+	db, err := sqly.New(&sqly.Option{
+		Dsn:          "file:" + filepath.Join(t.TempDir(), "migrate.db"),
+		DriverName:   "sqlite3",
+		MaxOpenConns: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testFS, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 3 || migrations[0].Version != 1 || migrations[1].Name != "add_mobile" ||
+		migrations[2].Down != "" || len(migrations[0].Checksum) != 64 {
+		t.Errorf("got %+v", migrations)
+	}
+
+	fsys := fstest.MapFS{"0001_a.up.sql": {}, "1_b.up.sql": {}}
+	if _, err := Load(fsys, "."); !errors.Is(err, ErrDuplicateVersion) {
+		t.Errorf("expect ErrDuplicateVersion, got %v", err)
+	}
+	fsys = fstest.MapFS{"0001_a.down.sql": {}}
+	if _, err := Load(fsys, "."); err == nil {
+		t.Error("expect error of missing up migration")
+	}
+}
+
+func TestSqlite_Migrate(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+	m, err := New(db, testFS, &Options{Dir: "migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := m.Up(ctx)
+	if err != nil || n != 3 {
+		t.Fatalf("up got %d %v", n, err)
+	}
+	if n, err := m.Up(ctx); err != nil || n != 0 {
+		t.Errorf("up again got %d %v", n, err)
+	}
+	var nickname string
+	if err := db.GetCtx(ctx, &nickname, "SELECT nickname FROM account WHERE mobile = ?", "1"); err != nil || nickname != "a;b" {
+		t.Errorf("got %s %v", nickname, err)
+	}
+
+	// the latest migration has no down migration
+	if _, err := m.Down(ctx); !errors.Is(err, ErrNoDown) {
+		t.Errorf("expect ErrNoDown, got %v", err)
+	}
+	if _, err := db.ExecCtx(ctx, "DELETE FROM "+DefaultTable+" WHERE version = 3"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := m.Redo(ctx); err != nil || v != 2 {
+		t.Errorf("redo got %d %v", v, err)
+	}
+	if v, err := m.Down(ctx); err != nil || v != 2 {
+		t.Errorf("down got %d %v", v, err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 3 || !status[0].Applied || status[0].AppliedAt.IsZero() || status[1].Applied || status[2].Applied {
+		t.Errorf("got %+v", status)
+	}
+
+	// applied migration is modified, or its file is removed
+	modified := fstest.MapFS{
+		"migrations/0001_create_account.up.sql": {Data: []byte("CREATE TABLE account (id INTEGER PRIMARY KEY);\n")},
+	}
+	m, err = New(db, modified, &Options{Dir: "migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expect ErrChecksumMismatch, got %v", err)
+	}
+	m, err = New(db, fstest.MapFS{"migrations": {Mode: fs.ModeDir | 0755}}, &Options{Dir: "migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 1 || !status[0].Missing || status[0].Name != "create_account" {
+		t.Errorf("got %+v", status)
+	}
+
+	// failed migration is rolled back with its record
+	broken := fstest.MapFS{
+		"0001_create_account.up.sql": testFS["migrations/0001_create_account.up.sql"],
+		"0002_broken.up.sql":         {Data: []byte("CREATE TABLE tmp (id INTEGER);\nINSERT INTO nothing VALUES (1);\n")},
+	}
+	if m, err = New(db, broken, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err == nil {
+		t.Error("expect error of broken migration")
+	}
+	var tables []string
+	if err := db.QueryCtx(ctx, &tables, "SELECT name FROM sqlite_master WHERE name = 'tmp'"); err != nil || len(tables) != 0 {
+		t.Errorf("got %v %v", tables, err)
+	}
+}
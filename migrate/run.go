@@ -0,0 +1,212 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/FeifeiyuM/sqly"
+)
+
+// ddl of schema table
+func (m *Migrator) createTable() string {
+	switch m.db.DriverName() {
+	case "postgres":
+		return "CREATE TABLE IF NOT EXISTS " + m.table + " (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, " +
+			"checksum CHAR(64) NOT NULL, applied_at TIMESTAMP NOT NULL)"
+	case "sqlserver":
+		return "IF OBJECT_ID(N'" + m.table + "', N'U') IS NULL CREATE TABLE " + m.table + " (version BIGINT PRIMARY KEY, " +
+			"name NVARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, applied_at DATETIME2 NOT NULL)"
+	}
+	return "CREATE TABLE IF NOT EXISTS " + m.table + " (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, " +
+		"checksum CHAR(64) NOT NULL, applied_at DATETIME NOT NULL)"
+}
+
+// ddl is transactional, migration and its record are committed together,
+// otherwise (mysql) the statements are committed one by one
+func (m *Migrator) transactional() bool {
+	return m.db.DriverName() != "mysql"
+}
+
+// run fn holding the migration lock, so replicas do not migrate at the same time,
+// sqlite has no advisory lock, concurrent migrations fail on the primary key of version
+func (m *Migrator) lock(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.db.DriverName() == "sqlite3" {
+		return fn(ctx)
+	}
+	return m.db.WithLock(ctx, "sqly_migrate:"+m.table, fn)
+}
+
+// applied versions, sorted by version
+func (m *Migrator) applied(ctx context.Context) ([]*record, error) {
+	if _, err := m.db.ExecCtx(ctx, m.createTable()); err != nil {
+		return nil, err
+	}
+	var records []*record
+	err := m.db.QueryCtx(ctx, &records, "SELECT version, name, checksum, applied_at FROM "+m.table+" ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// run script of migration and record it, up inserts the record and down deletes it
+func (m *Migrator) run(ctx context.Context, mig *Migration, up bool) error {
+	script := mig.Up
+	stmt := "INSERT INTO " + m.table + " (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)"
+	args := []interface{}{mig.Version, mig.Name, mig.Checksum, time.Now()}
+	if !up {
+		script = mig.Down
+		stmt = "DELETE FROM " + m.table + " WHERE version = ?"
+		args = args[:1]
+	}
+	if !m.transactional() {
+		if err := m.db.ExecManyCtx(ctx, sqly.SplitStatements(script)); err != nil {
+			return err
+		}
+		_, err := m.db.ExecCtx(ctx, stmt, args...)
+		return err
+	}
+	tx, err := m.db.NewTrans()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := tx.ExecManyCtx(ctx, []string{script}); err != nil {
+		return err
+	}
+	if _, err := tx.ExecCtx(ctx, stmt, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// check checksums of applied migrations
+func (m *Migrator) verify(records []*record) (map[int64]*record, error) {
+	applied := make(map[int64]*record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	for _, mig := range m.migrations {
+		if r, ok := applied[mig.Version]; ok && r.Checksum != mig.Checksum {
+			return nil, fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, ErrChecksumMismatch)
+		}
+	}
+	return applied, nil
+}
+
+// Up apply all pending migrations in order of version, returns the number of migrations applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	n := 0
+	err := m.lock(ctx, func(ctx context.Context) error {
+		records, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		applied, err := m.verify(records)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, mig, true); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// latest applied migration
+func (m *Migrator) latest(ctx context.Context) (*Migration, error) {
+	records, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrNoApplied
+	}
+	last := records[len(records)-1]
+	for _, mig := range m.migrations {
+		if mig.Version == last.Version {
+			if mig.Down == "" {
+				return nil, fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, ErrNoDown)
+			}
+			return mig, nil
+		}
+	}
+	return nil, fmt.Errorf("migration %d_%s: %w", last.Version, last.Name, ErrNoDown)
+}
+
+// Down roll back the latest applied migration, returns its version
+func (m *Migrator) Down(ctx context.Context) (int64, error) {
+	var version int64
+	err := m.lock(ctx, func(ctx context.Context) error {
+		mig, err := m.latest(ctx)
+		if err != nil {
+			return err
+		}
+		if err := m.run(ctx, mig, false); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		version = mig.Version
+		return nil
+	})
+	return version, err
+}
+
+// Redo roll back the latest applied migration and apply it again, returns its version
+func (m *Migrator) Redo(ctx context.Context) (int64, error) {
+	var version int64
+	err := m.lock(ctx, func(ctx context.Context) error {
+		mig, err := m.latest(ctx)
+		if err != nil {
+			return err
+		}
+		if err := m.run(ctx, mig, false); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		if err := m.run(ctx, mig, true); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		version = mig.Version
+		return nil
+	})
+	return version, err
+}
+
+// Status status of migrations and applied versions, sorted by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	records, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]*record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	var res []Status
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if r, ok := applied[mig.Version]; ok {
+			s.Applied, s.AppliedAt, s.Modified = true, r.AppliedAt, r.Checksum != mig.Checksum
+			delete(applied, mig.Version)
+		}
+		res = append(res, s)
+	}
+	// applied versions whose migration files are missing
+	for _, r := range applied {
+		res = append(res, Status{Version: r.Version, Name: r.Name, Applied: true, AppliedAt: r.AppliedAt, Missing: true})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Version < res[j].Version
+	})
+	return res, nil
+}
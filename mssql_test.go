@@ -0,0 +1,221 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newMssqlDb(t *testing.T, handler fakeHandler) *SqlY {
+	fakeMssql.reset(handler)
+	db, err := New(&Option{Dsn: "sqlserver://fake", DriverName: "sqlserver"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestQueryFmtMssql(t *testing.T) {
+	tm := time.Date(2021, 5, 1, 8, 30, 0, 1000, time.UTC)
+	res, err := QueryFmtMssql("INSERT INTO [account] ([nickname], [is_valid], [add_time], [avatar]) VALUES (?, ?, ?, ?)",
+		"it's 中文\\", true, tm, []byte{0x01, 0xab})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resCmp := "INSERT INTO [account] ([nickname], [is_valid], [add_time], [avatar]) VALUES " +
+		"(N'it''s 中文\\', 1, '2021-05-01T08:30:00.000001', 0x01ab)"
+	if res != resCmp {
+		t.Errorf("got %s", res)
+	}
+}
+
+func TestMssql_Rebind(t *testing.T) {
+	db := newMssqlDb(t, nil)
+	q := db.Rebind("SELECT * FROM [what?] WHERE [a]=? AND [b]='?' AND [c] IN (?, ?)")
+	if q != "SELECT * FROM [what?] WHERE [a]=@p1 AND [b]='?' AND [c] IN (@p2, @p3)" {
+		t.Errorf("got %s", q)
+	}
+	if rebind(driverPostgresql, "a=? AND b=?") != "a=$1 AND b=$2" {
+		t.Error("pg rebind")
+	}
+	if rebind(driverMysql, "a=? AND b=?") != "a=? AND b=?" {
+		t.Error("mysql rebind")
+	}
+	// backslash is not an escape in standard strings, but it is in E'...' strings of postgresql
+	if q := rebind(driverPostgresql, "SELECT '\\', ?, E'\\'?', ?"); q != "SELECT '\\', $1, E'\\'?', $2" {
+		t.Errorf("got %s", q)
+	}
+	if q := rebind(driverMssql, "SELECT N'C:\\dir\\', ?"); q != "SELECT N'C:\\dir\\', @p1" {
+		t.Errorf("got %s", q)
+	}
+}
+
+func TestMssql_PgExec(t *testing.T) {
+	db := newMssqlDb(t, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{
+			columns: []fakeColumn{{name: "id", typeName: "INT"}},
+			rows:    [][]driver.Value{{int64(12)}},
+		}, nil
+	})
+	aff, err := db.PgExec("id", "INSERT INTO [account] ([nickname]) VALUES (?);", "nick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := aff.GetLastId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 12 {
+		t.Errorf("last id %d", id)
+	}
+	qs := fakeMssql.recorded()
+	if qs[len(qs)-1] != "INSERT INTO [account] ([nickname]) OUTPUT INSERTED.id VALUES (N'nick')" {
+		t.Errorf("got %s", qs[len(qs)-1])
+	}
+	if q := returningFmt(driverMssql, "UPDATE [account] SET [role]=1 WHERE [id]=2", "id"); q !=
+		"UPDATE [account] SET [role]=1 OUTPUT INSERTED.id WHERE [id]=2" {
+		t.Errorf("got %s", q)
+	}
+	// keywords in subqueries and strings are skipped
+	if q := returningFmt(driverMssql, "UPDATE [account] SET [role]=(SELECT [id] FROM [role] WHERE [name]='admin'), "+
+		"[nickname]='a where b' WHERE [id]=2", "id"); q != "UPDATE [account] SET [role]=(SELECT [id] FROM [role] "+
+		"WHERE [name]='admin'), [nickname]='a where b' OUTPUT INSERTED.id WHERE [id]=2" {
+		t.Errorf("got %s", q)
+	}
+	if q := returningFmt(driverMssql, "INSERT INTO [account] ([nickname]) VALUES ('select')", "id"); q !=
+		"INSERT INTO [account] ([nickname]) OUTPUT INSERTED.id VALUES ('select')" {
+		t.Errorf("got %s", q)
+	}
+	if q := returningAllFmt(driverPostgresql, "INSERT INTO account (nickname) VALUES ('returning')"); q !=
+		"INSERT INTO account (nickname) VALUES ('returning') RETURNING *" {
+		t.Errorf("got %s", q)
+	}
+	if q := returningFmt(driverMssql, "UPDATE t SET p = N'C:\\dir\\' WHERE id = 1", "id"); q !=
+		"UPDATE t SET p = N'C:\\dir\\' OUTPUT INSERTED.id WHERE id = 1" {
+		t.Errorf("got %s", q)
+	}
+	if q := returningAllFmt(driverSqlite, "INSERT INTO t (p) VALUES ('C:\\') RETURNING id"); q !=
+		"INSERT INTO t (p) VALUES ('C:\\') RETURNING id" {
+		t.Errorf("got %s", q)
+	}
+}
+
+func TestMssql_LimitFmt(t *testing.T) {
+	q := limitFmt(driverMssql, "SELECT DISTINCT [id] FROM [account]", 10, 0)
+	if q != "SELECT DISTINCT TOP 10 [id] FROM [account]" {
+		t.Errorf("got %s", q)
+	}
+	q = limitFmt(driverMssql, "SELECT [id] FROM [account] ORDER BY [id]", 10, 20)
+	if q != "SELECT [id] FROM [account] ORDER BY [id] OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY" {
+		t.Errorf("got %s", q)
+	}
+	q = limitFmt(driverMssql, "SELECT [id], (SELECT MAX([id]) FROM [role] ORDER BY [id]) FROM [account]", 10, 0)
+	if q != "SELECT TOP 10 [id], (SELECT MAX([id]) FROM [role] ORDER BY [id]) FROM [account]" {
+		t.Errorf("got %s", q)
+	}
+	q = limitFmt(driverMysql, "SELECT `id` FROM `account`;", 10, 20)
+	if q != "SELECT `id` FROM `account` LIMIT 10 OFFSET 20" {
+		t.Errorf("got %s", q)
+	}
+}
+
+func TestMssql_QueryMap(t *testing.T) {
+	tm := time.Now()
+	db := newMssqlDb(t, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{
+			columns: []fakeColumn{
+				{name: "id", typeName: "BIGINT"},
+				{name: "nickname", typeName: "NVARCHAR", nullable: true},
+				{name: "is_valid", typeName: "BIT", nullable: true, scanType: reflect.TypeOf(true)},
+				{name: "add_time", typeName: "DATETIME2"},
+				{name: "balance", typeName: "MONEY", nullable: true},
+			},
+			rows: [][]driver.Value{{int64(1), "lucy", true, tm, []byte("12.5000")}},
+		}, nil
+	})
+	res := make(map[string]interface{})
+	if err := db.GetCtx(context.TODO(), &res, "SELECT * FROM [account] WHERE [nickname]=?", "lucy"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := res["nickname"].(*NullString); !ok || v.String != "lucy" {
+		t.Errorf("nickname %v", res["nickname"])
+	}
+	if v, ok := res["is_valid"].(*NullBool); !ok || !v.Bool {
+		t.Errorf("is_valid %v", res["is_valid"])
+	}
+	if v, ok := res["add_time"].(*time.Time); !ok || !v.Equal(tm) {
+		t.Errorf("add_time %v", res["add_time"])
+	}
+	if v, ok := res["balance"].(*NullFloat64); !ok || v.Float64 != 12.5 {
+		t.Errorf("balance %v", res["balance"])
+	}
+	qs := fakeMssql.recorded()
+	if !strings.HasSuffix(qs[len(qs)-1], "[nickname]=N'lucy'") {
+		t.Errorf("got %s", qs[len(qs)-1])
+	}
+}
+
+func TestMssql_ExecReturning(t *testing.T) {
+	db := newMssqlDb(t, nil)
+	var id int64
+	err := db.ExecReturning(context.TODO(), &id, "INSERT INTO [account] ([nickname]) VALUES (?)", "nick")
+	if !errors.Is(err, ErrNotSupportForThisDriver) {
+		t.Errorf("got %v", err)
+	}
+}
+
+// sql server rejects insert statement with more than 1000 rows
+func TestMssql_InsertManyChunks(t *testing.T) {
+	var id int64
+	db := newMssqlDb(t, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if !strings.HasPrefix(query, "INSERT") {
+			return &fakeResult{}, nil
+		}
+		res := &fakeResult{columns: []fakeColumn{{name: "id", typeName: "INT"}}, aff: int64(strings.Count(query, "(N'"))}
+		for i := int64(0); i < res.aff; i++ {
+			id++
+			res.rows = append(res.rows, []driver.Value{id})
+		}
+		return res, nil
+	})
+	args := make([][]interface{}, 1500)
+	for i := range args {
+		args[i] = []interface{}{"nick"}
+	}
+	query := "INSERT INTO [account] ([nickname]) VALUES (?)"
+	if _, err := db.InsertMany(query, args); err != nil {
+		t.Fatal(err)
+	}
+	if qs := fakeMssql.recorded(); len(qs) != 4 || strings.Count(qs[1], "(N'") != 1000 || strings.Count(qs[2], "(N'") != 500 {
+		t.Errorf("got %d statements", len(qs))
+	}
+
+	fakeMssql.reset(fakeMssql.handler)
+	ids, err := db.InsertManyReturningIDs(context.TODO(), "id", query, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1500 || ids[0] != 1501 || ids[1499] != 3000 {
+		t.Errorf("got %d ids", len(ids))
+	}
+	qs := fakeMssql.recorded()
+	if len(qs) != 4 || qs[0] != "BEGIN" || !strings.Contains(qs[1], "OUTPUT INSERTED.id") || qs[3] != "COMMIT" {
+		t.Errorf("got %d statements", len(qs))
+	}
+
+	// smaller chunk of option is kept
+	db, err = New(&Option{Dsn: "sqlserver://fake", DriverName: "sqlserver", InsertChunkRows: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.chunkRows != 100 {
+		t.Errorf("chunk rows %d", db.chunkRows)
+	}
+	if db, _ = New(&Option{Dsn: "sqlserver://fake", DriverName: "sqlserver", InsertChunkRows: 5000}); db.chunkRows != mssqlMaxRows {
+		t.Errorf("chunk rows %d", db.chunkRows)
+	}
+}
@@ -0,0 +1,14 @@
+// Package mysqlreader registers reader handler functions of go-sql-driver/mysql for LoadData of sqly,
+// it's imported for side effects:
+//
+//	import _ "github.com/FeifeiyuM/sqly/mysqlreader"
+package mysqlreader
+
+import (
+	"github.com/FeifeiyuM/sqly"
+	"github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	sqly.RegisterReaderHandler(mysql.RegisterReaderHandler, mysql.DeregisterReaderHandler)
+}
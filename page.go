@@ -0,0 +1,221 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PageOpts options of pagination,
+// keyset mode (seek on Key) is used if Key is set, otherwise offset mode (Page)
+type PageOpts struct {
+	Size int64 // rows of page
+	// offset mode
+	Page  int64 // page number, starts from 1
+	Count bool  // query total number of rows
+	// keyset mode
+	Key    string // sort key, which should be unique and selected by query, such as id
+	Desc   bool   // sort key descending
+	Cursor string // cursor returned by previous page, empty for the first page
+}
+
+// Page result of pagination
+type Page struct {
+	Total      int64  // total number of rows, -1 if it's not counted
+	HasMore    bool   // there are more rows after this page
+	NextCursor string // cursor of next page in keyset mode, empty if there are no more rows
+}
+
+// index of the top level ORDER BY clause of query
+func indexOrderBy(driver dbDriver, query string) int {
+	return indexKeyword(driver, query, "ORDER BY")
+}
+
+// query without the top level ORDER BY clause
+func trimOrderBy(driver dbDriver, query string) string {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if i := indexOrderBy(driver, query); i >= 0 {
+		return strings.TrimSpace(query[:i])
+	}
+	return query
+}
+
+// cursor value with its type
+type pageCursor struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// encode sort key value as opaque cursor
+func encodeCursor(val interface{}) (string, error) {
+	// sqly null types
+	val = bindArg(driverOthers, val)
+	if v, ok := val.(driver.Valuer); ok {
+		var err error
+		if val, err = v.Value(); err != nil {
+			return "", err
+		}
+	}
+	var c pageCursor
+	switch v := val.(type) {
+	case time.Time:
+		c = pageCursor{Type: "t", Value: v.Format(time.RFC3339Nano)}
+	case []byte:
+		c = pageCursor{Type: "s", Value: string(v)}
+	default:
+		rv := reflect.ValueOf(val)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			c = pageCursor{Type: "i", Value: strconv.FormatInt(rv.Int(), 10)}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			c = pageCursor{Type: "u", Value: strconv.FormatUint(rv.Uint(), 10)}
+		case reflect.Float32, reflect.Float64:
+			c = pageCursor{Type: "f", Value: strconv.FormatFloat(rv.Float(), 'g', -1, 64)}
+		case reflect.String:
+			c = pageCursor{Type: "s", Value: rv.String()}
+		default:
+			return "", ErrArgType
+		}
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decode cursor to sort key value
+func decodeCursor(cursor string) (interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var val interface{}
+	switch c.Type {
+	case "t":
+		val, err = time.Parse(time.RFC3339Nano, c.Value)
+	case "i":
+		val, err = strconv.ParseInt(c.Value, 10, 64)
+	case "u":
+		val, err = strconv.ParseUint(c.Value, 10, 64)
+	case "f":
+		val, err = strconv.ParseFloat(c.Value, 64)
+	case "s":
+		val = c.Value
+	default:
+		return nil, ErrInvalidCursor
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return val, nil
+}
+
+// value of sort key of row, row is struct, map or the key itself
+func keyValue(row reflect.Value, key string) (interface{}, error) {
+	row = reflect.Indirect(row)
+	switch row.Kind() {
+	case reflect.Struct:
+		// time, null types
+		if scanAble(row.Type()) {
+			return row.Interface(), nil
+		}
+		for _, m := range structFieldsMeta(row.Type()) {
+			if m.column == key {
+				if fv := fieldValue(row, m.pos); fv.IsValid() {
+					return fv.Interface(), nil
+				}
+			}
+		}
+		return nil, ErrFieldsMatch
+	case reflect.Map:
+		if m, ok := row.Interface().(map[string]interface{}); ok {
+			if v, ok := m[key]; ok {
+				return v, nil
+			}
+		}
+		return nil, ErrFieldsMatch
+	}
+	return row.Interface(), nil
+}
+
+// paginate query rows of page to dest (slice pointer)
+func paginate(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, dest interface{}, query string,
+	opts PageOpts, args ...interface{}) (*Page, error) {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Slice {
+		return nil, ErrContainer
+	}
+	if opts.Size <= 0 {
+		return nil, ErrArgType
+	}
+	page := &Page{Total: -1}
+	var stmt string
+	if opts.Key == "" {
+		if opts.Count {
+			count, err := statementFormat("SELECT COUNT(*) FROM ("+trimOrderBy(driver, query)+") AS _count", argFmt, args...)
+			if err != nil {
+				return nil, err
+			}
+			if err := q.QueryRowContext(ctx, count).Scan(&page.Total); err != nil {
+				return nil, err
+			}
+		}
+		num := opts.Page
+		if num < 1 {
+			num = 1
+		}
+		// one more row to know whether there are more rows
+		stmt = limitFmt(driver, strings.TrimRight(strings.TrimSpace(query), ";"), opts.Size+1, (num-1)*opts.Size)
+	} else {
+		op, order := " > ", " ASC"
+		if opts.Desc {
+			op, order = " < ", " DESC"
+		}
+		stmt = "SELECT * FROM (" + trimOrderBy(driver, query) + ") AS _page"
+		if opts.Cursor != "" {
+			cur, err := decodeCursor(opts.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			stmt += " WHERE " + opts.Key + op + "?"
+			args = append(append([]interface{}{}, args...), cur)
+		}
+		stmt = limitFmt(driver, stmt+" ORDER BY "+opts.Key+order, opts.Size+1, 0)
+	}
+	stmt, err := statementFormat(stmt, argFmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAllV2(rows, dest); err != nil {
+		return nil, err
+	}
+	list := val.Elem()
+	if int64(list.Len()) > opts.Size {
+		page.HasMore = true
+		list.Set(list.Slice(0, int(opts.Size)))
+	}
+	if opts.Key != "" && page.HasMore {
+		key, err := keyValue(list.Index(list.Len()-1), opts.Key)
+		if err != nil {
+			return nil, err
+		}
+		if page.NextCursor, err = encodeCursor(key); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,77 @@
+package sqly
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestPageCursor(t *testing.T) {
+	for _, val := range []interface{}{int64(42), uint64(1 << 63), "lucy", 1.5} {
+		cursor, err := encodeCursor(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeCursor(cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != val {
+			t.Errorf("got %v, want %v", got, val)
+		}
+	}
+	if _, err := decodeCursor("not a cursor"); err != ErrInvalidCursor {
+		t.Errorf("expect ErrInvalidCursor, got %v", err)
+	}
+	if q := trimOrderBy(driverMysql, "SELECT * FROM (SELECT id FROM t ORDER BY id) AS a ORDER BY id DESC;"); q != "SELECT * FROM (SELECT id FROM t ORDER BY id) AS a" {
+		t.Errorf("got %s", q)
+	}
+}
+
+func TestSqlite_Paginate(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+	var rows [][]interface{}
+	for i := 0; i < 5; i++ {
+		rows = append(rows, []interface{}{fmt.Sprintf("user%d", i), fmt.Sprintf("1881231123%d", i), i % 2})
+	}
+	if _, err := db.InsertMany("INSERT INTO `account` (`nickname`, `mobile`, `role`) VALUES (?, ?, ?)", rows); err != nil {
+		t.Fatal(err)
+	}
+	query := "SELECT `id`, `nickname` FROM `account` WHERE `role` IN ? ORDER BY `id`"
+
+	// offset
+	var accs []*Account
+	page, err := db.Paginate(ctx, &accs, query, PageOpts{Size: 2, Page: 2, Count: true}, []int{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 5 || !page.HasMore || len(accs) != 2 || accs[0].Nickname != "user2" {
+		t.Errorf("unexpected page %+v %v", page, accs)
+	}
+
+	// keyset
+	var nicknames []string
+	var cursor string
+	for {
+		var list []map[string]interface{}
+		page, err := db.Paginate(ctx, &list, query, PageOpts{Size: 2, Key: "id", Desc: true, Cursor: cursor}, []int{0, 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != -1 {
+			t.Errorf("total is counted in keyset mode")
+		}
+		for _, m := range list {
+			nicknames = append(nicknames, m["nickname"].(*NullString).String)
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if fmt.Sprint(nicknames) != "[user4 user3 user2 user1 user0]" {
+		t.Errorf("got %v", nicknames)
+	}
+}
@@ -0,0 +1,284 @@
+// Package pqlisten subscribes channels of postgresql LISTEN with pq.Listener of lib/pq,
+// it's separated from sqly, which does not depend on the driver, NOTIFY is sent by SqlY.Notify
+package pqlisten
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notification notification of postgresql NOTIFY,
+// Channel is empty if notifications may be missed, as the listener reconnected or the subscriber is too slow,
+// subscriber should reload the state it keeps in sync by notifications
+type Notification struct {
+	Channel string // channel name, empty if notifications may be missed
+	Payload string // payload, empty if unspecified
+	PID     int    // process id of the notifying backend
+}
+
+// pgListener methods of pq.Listener used by Listener
+type pgListener interface {
+	Listen(channel string) error
+	Unlisten(channel string) error
+	Ping() error
+	Close() error
+	NotificationChannel() <-chan *pq.Notification
+}
+
+// listener reconnect interval
+const (
+	listenMinReconnect = 100 * time.Millisecond
+	listenMaxReconnect = time.Minute
+	listenPingInterval = 90 * time.Second
+)
+
+// size of notification buffer of each subscription
+const listenBuffer = 16
+
+// subscription of a channel
+type subscription struct {
+	ch     chan Notification
+	mu     sync.Mutex
+	lost   bool // notifications are dropped, the subscriber is not told yet
+	closed bool
+}
+
+// tell subscriber that notifications may be missed, lock is held,
+// it returns false if the buffer is still full
+func (s *subscription) flush() bool {
+	if !s.lost {
+		return true
+	}
+	select {
+	case s.ch <- Notification{}:
+		s.lost = false
+		return true
+	default:
+		return false
+	}
+}
+
+// send notification without blocking the dispatcher, it's dropped if the buffer is full,
+// and the subscriber is told by a notification with empty Channel when there is room again
+func (s *subscription) send(msg Notification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || !s.flush() {
+		return
+	}
+	select {
+	case s.ch <- msg:
+	default:
+		s.lost = true
+	}
+}
+
+// notifications may be missed, such as the listener reconnected
+func (s *subscription) miss() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.lost = true
+	s.flush()
+}
+
+// close the notification channel
+func (s *subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Listener shares one listener connection among the subscriptions of all channels,
+// the connection is closed when there are no subscriptions
+type Listener struct {
+	mu       sync.Mutex
+	cmdMu    sync.Mutex // serializes LISTEN and UNLISTEN, which wait until the listener is connected
+	dial     func() pgListener
+	listener *listenConn
+	subs     map[string][]*subscription
+}
+
+// listener connection and the channels it listens, channels is guarded by cmdMu of Listener
+type listenConn struct {
+	pgListener
+	channels map[string]bool
+}
+
+// New create Listener connecting to dsn of postgresql, the connection is opened by the first subscription
+func New(dsn string) *Listener {
+	return &Listener{
+		dial: func() pgListener {
+			return pq.NewListener(dsn, listenMinReconnect, listenMaxReconnect, nil)
+		},
+		subs: make(map[string][]*subscription),
+	}
+}
+
+// Listen subscribe channel until ctx is done, then the returned channel is closed,
+// notification with empty Channel is received after reconnection, or notifications are dropped as the receiver is slow.
+// it waits until the channel is listened, and returns ctx.Err() if ctx is done before that, such as the server is unreachable
+func (n *Listener) Listen(ctx context.Context, channel string) (<-chan Notification, error) {
+	n.mu.Lock()
+	if n.listener == nil {
+		n.listener = &listenConn{pgListener: n.dial(), channels: make(map[string]bool)}
+		go n.dispatch(n.listener)
+	}
+	lc := n.listener
+	sub := &subscription{ch: make(chan Notification, listenBuffer)}
+	n.subs[channel] = append(n.subs[channel], sub)
+	n.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- n.sync(lc, channel)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			n.unsubscribe(channel, sub)
+			return nil, err
+		}
+	case <-ctx.Done():
+		n.unsubscribe(channel, sub)
+		return nil, ctx.Err()
+	}
+	go func() {
+		<-ctx.Done()
+		n.unsubscribe(channel, sub)
+	}()
+	return sub.ch, nil
+}
+
+// sync LISTEN or UNLISTEN channel on lc as there are subscriptions of it or not,
+// n.mu is not held while the command waits for connection
+func (n *Listener) sync(lc *listenConn, channel string) error {
+	n.cmdMu.Lock()
+	defer n.cmdMu.Unlock()
+	n.mu.Lock()
+	closed, want := n.listener != lc, len(n.subs[channel]) > 0
+	n.mu.Unlock()
+	if closed || want == lc.channels[channel] {
+		return nil
+	}
+	var err error
+	if want {
+		if err = lc.Listen(channel); err == pq.ErrChannelAlreadyOpen {
+			err = nil
+		}
+	} else {
+		err = lc.Unlisten(channel)
+	}
+	if err != nil {
+		return err
+	}
+	lc.channels[channel] = want
+	return nil
+}
+
+// unsubscribe and close the notification channel of sub
+func (n *Listener) unsubscribe(channel string, sub *subscription) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	subs := n.subs[channel]
+	for i, s := range subs {
+		if s == sub {
+			subs = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	sub.close()
+	if len(subs) > 0 {
+		n.subs[channel] = subs
+		return
+	}
+	delete(n.subs, channel)
+	if n.listener == nil {
+		return
+	}
+	if len(n.subs) == 0 {
+		n.closeListener()
+		return
+	}
+	lc := n.listener
+	go func() {
+		_ = n.sync(lc, channel)
+	}()
+}
+
+// close listener, lock is held, commands waiting for connection return error
+func (n *Listener) closeListener() {
+	_ = n.listener.Close()
+	n.listener = nil
+}
+
+// dispatch notifications to subscriptions of channel, until listener is closed,
+// pq.Listener reconnects automatically, and sends nil after reconnected,
+// which is dispatched to all subscriptions as notification with empty Channel
+func (n *Listener) dispatch(l *listenConn) {
+	ch := l.NotificationChannel()
+	for {
+		select {
+		case pn, ok := <-ch:
+			if !ok {
+				return
+			}
+			if pn == nil {
+				n.reconnected()
+				continue
+			}
+			n.deliver(Notification{Channel: pn.Channel, Payload: pn.Extra, PID: pn.BePid})
+		case <-time.After(listenPingInterval):
+			// detect broken connection
+			go func() {
+				_ = l.Ping()
+			}()
+		}
+	}
+}
+
+// deliver notification to subscriptions of channel
+func (n *Listener) deliver(msg Notification) {
+	n.mu.Lock()
+	subs := append([]*subscription(nil), n.subs[msg.Channel]...)
+	n.mu.Unlock()
+	for _, sub := range subs {
+		sub.send(msg)
+	}
+}
+
+// notifications are missed while listener is reconnecting, all subscriptions are told
+func (n *Listener) reconnected() {
+	n.mu.Lock()
+	var subs []*subscription
+	for _, ss := range n.subs {
+		subs = append(subs, ss...)
+	}
+	n.mu.Unlock()
+	for _, sub := range subs {
+		sub.miss()
+	}
+}
+
+// Close close all subscriptions and the listener connection
+func (n *Listener) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, subs := range n.subs {
+		for _, sub := range subs {
+			sub.close()
+		}
+	}
+	n.subs = make(map[string][]*subscription)
+	if n.listener != nil {
+		n.closeListener()
+	}
+}
@@ -0,0 +1,243 @@
+package pqlisten
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type fakeListener struct {
+	mu        sync.Mutex
+	channels  map[string]bool
+	ch        chan *pq.Notification
+	closed    bool
+	connected chan struct{} // Listen waits until it's closed, nil if connected
+	done      chan struct{}
+}
+
+func newFakeListener() *fakeListener {
+	return &fakeListener{channels: make(map[string]bool), ch: make(chan *pq.Notification), done: make(chan struct{})}
+}
+
+// Listen waits until the listener is connected as pq.Listener does
+func (l *fakeListener) Listen(channel string) error {
+	if l.connected != nil {
+		select {
+		case <-l.connected:
+		case <-l.done:
+			return errors.New("listener has been closed")
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.channels[channel] = true
+	return nil
+}
+
+func (l *fakeListener) Unlisten(channel string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.channels, channel)
+	return nil
+}
+
+func (l *fakeListener) Ping() error {
+	return nil
+}
+
+func (l *fakeListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	close(l.ch)
+	close(l.done)
+	return nil
+}
+
+func (l *fakeListener) NotificationChannel() <-chan *pq.Notification {
+	return l.ch
+}
+
+func (l *fakeListener) listening(channel string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.channels[channel]
+}
+
+func TestNotifier(t *testing.T) {
+	var dialed []*fakeListener
+	n := New("")
+	n.dial = func() pgListener {
+		l := newFakeListener()
+		dialed = append(dialed, l)
+		return l
+	}
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	ch1, err := n.Listen(ctx1, "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch2, err := n.Listen(ctx2, "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := dialed[0]
+	if len(dialed) != 1 || !l.listening("cache") || !l.listening("config") {
+		t.Fatalf("channels are not listened by one listener")
+	}
+
+	l.ch <- nil // reconnected
+	l.ch <- &pq.Notification{Channel: "cache", Extra: "account:1", BePid: 7}
+	l.ch <- &pq.Notification{Channel: "config", Extra: "reload"}
+	// subscriptions are told of reconnection by empty channel
+	if msg := <-ch1; msg.Channel != "" {
+		t.Errorf("got %+v", msg)
+	}
+	if msg := <-ch2; msg.Channel != "" {
+		t.Errorf("got %+v", msg)
+	}
+	if msg := <-ch1; msg.Payload != "account:1" || msg.PID != 7 {
+		t.Errorf("got %+v", msg)
+	}
+	if msg := <-ch2; msg.Channel != "config" || msg.Payload != "reload" {
+		t.Errorf("got %+v", msg)
+	}
+
+	cancel1()
+	if _, ok := <-ch1; ok {
+		t.Errorf("channel is not closed")
+	}
+	waitFor(t, func() bool { return !l.listening("cache") })
+
+	// listener is closed with the last subscription
+	cancel2()
+	if _, ok := <-ch2; ok {
+		t.Errorf("channel is not closed")
+	}
+	waitFor(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.closed
+	})
+}
+
+func TestNotifier_SlowSubscriber(t *testing.T) {
+	l := newFakeListener()
+	n := New("")
+	n.dial = func() pgListener {
+		return l
+	}
+	defer n.Close()
+	slow, err := n.Listen(context.Background(), "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, err := n.Listen(context.Background(), "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the buffer of slow subscriber is full, its notifications are dropped
+	for i := 0; i < listenBuffer+5; i++ {
+		l.ch <- &pq.Notification{Channel: "cache", Extra: "account"}
+	}
+	// other subscribers are not blocked
+	l.ch <- &pq.Notification{Channel: "config", Extra: "reload"}
+	if msg := <-fast; msg.Payload != "reload" {
+		t.Errorf("got %+v", msg)
+	}
+	for i := 0; i < listenBuffer; i++ {
+		if msg := <-slow; msg.Payload != "account" {
+			t.Errorf("got %+v", msg)
+		}
+	}
+	// subscriber is told of the dropped notifications before the next one
+	l.ch <- &pq.Notification{Channel: "cache", Extra: "account:2"}
+	if msg := <-slow; msg.Channel != "" {
+		t.Errorf("got %+v", msg)
+	}
+	if msg := <-slow; msg.Payload != "account:2" {
+		t.Errorf("got %+v", msg)
+	}
+}
+
+// Listen does not block when the server is unreachable
+func TestNotifier_Unreachable(t *testing.T) {
+	l := newFakeListener()
+	l.connected = make(chan struct{})
+	n := New("")
+	n.dial = func() pgListener {
+		return l
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := n.Listen(ctx, "cache"); err != context.DeadlineExceeded {
+		t.Errorf("expect deadline exceeded, got %v", err)
+	}
+	// listener is closed with the subscription
+	waitFor(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.closed
+	})
+
+	// close is not blocked by subscription waiting for connection
+	l = newFakeListener()
+	l.connected = make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := n.Listen(context.Background(), "cache")
+		errCh <- err
+	}()
+	waitFor(t, func() bool {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		return len(n.subs["cache"]) > 0
+	})
+	closed := make(chan struct{})
+	go func() {
+		n.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("close is blocked")
+	}
+	if err := <-errCh; err == nil {
+		t.Errorf("expect error of closed listener")
+	}
+
+	// channel is listened once connected
+	l = newFakeListener()
+	l.connected = make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(l.connected)
+	}()
+	ch, err := n.Listen(context.Background(), "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.listening("cache") {
+		t.Errorf("channel is not listened")
+	}
+	n.Close()
+	if _, ok := <-ch; ok {
+		t.Errorf("channel is not closed")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timeout")
+}
@@ -3,6 +3,7 @@ package sqly
 import (
 	"bytes"
 	"strconv"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -106,3 +107,21 @@ func PgString(s string) string {
 	buff.WriteString(quoteWith(s, '\''))
 	return buff.String()
 }
+
+// SqliteString sqlite 字符串格式化, 单引号转义为两个单引号, 不支持反斜杠转义
+func SqliteString(s string) string {
+	var buff bytes.Buffer
+	buff.WriteByte('\'')
+	buff.WriteString(strings.Replace(s, "'", "''", -1))
+	buff.WriteByte('\'')
+	return buff.String()
+}
+
+// MssqlString sql server 字符串格式化, unicode 字符串 N'...', 单引号转义为两个单引号
+func MssqlString(s string) string {
+	var buff bytes.Buffer
+	buff.WriteString("N'")
+	buff.WriteString(strings.Replace(s, "'", "''", -1))
+	buff.WriteByte('\'')
+	return buff.String()
+}
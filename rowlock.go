@@ -0,0 +1,153 @@
+package sqly
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// RowLockOpts options of row level lock of select statement
+type RowLockOpts struct {
+	Share      bool // FOR SHARE, otherwise FOR UPDATE
+	NoWait     bool // fail immediately if rows are locked by others
+	SkipLocked bool // skip rows locked by others
+}
+
+// locking clause of select statement, postgresql and mysql 8 only
+func rowLockFmt(driver dbDriver, opts RowLockOpts) (string, error) {
+	if driver != driverPostgresql && driver != driverMysql {
+		return "", ErrNotSupportForThisDriver
+	}
+	if opts.NoWait && opts.SkipLocked {
+		return "", ErrArgType
+	}
+	clause := " FOR UPDATE"
+	if opts.Share {
+		clause = " FOR SHARE"
+	}
+	if opts.NoWait {
+		clause += " NOWAIT"
+	} else if opts.SkipLocked {
+		clause += " SKIP LOCKED"
+	}
+	return clause, nil
+}
+
+// select statement with locking clause
+func forUpdateFmt(driver dbDriver, query string, opts RowLockOpts) (string, error) {
+	clause, err := rowLockFmt(driver, opts)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(strings.TrimSpace(query), ";") + clause, nil
+}
+
+// column of the single primary key of struct element of dest (slice pointer)
+func claimKey(dest interface{}) (string, error) {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
+		return "", ErrContainer
+	}
+	t = t.Elem().Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return "", ErrContainer
+	}
+	key := ""
+	for _, m := range structFieldsMeta(t) {
+		if !m.pk {
+			continue
+		}
+		if key != "" {
+			return "", ErrNoPrimaryKey
+		}
+		key = m.column
+	}
+	if key == "" {
+		return "", ErrNoPrimaryKey
+	}
+	return key, nil
+}
+
+// claim up to n rows of table matching cond in order of primary key, skipping rows locked by others,
+// mark them with the columns of mark, and query them to dest, it should be run in transaction,
+// ErrEmptyArrayInStatement is returned if cond has empty array argument
+func claimBatch(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, n int64, dest interface{}, table string,
+	cond Cond, mark interface{}) error {
+	key, err := claimKey(dest)
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return ErrArgType
+	}
+	cols, vals, err := rowData(mark)
+	if err != nil {
+		return err
+	}
+	if len(cols) == 0 {
+		return ErrStatement
+	}
+	qKey, qTable := quoteIdent(driver, key), quoteIdent(driver, table)
+	// rows to claim are modified, condition with empty array is rejected as UpdateBuilder does
+	where, args, err := writeWhereFmt([]Cond{cond}, true)
+	if err != nil {
+		return err
+	}
+	query := limitFmt(driver, "SELECT "+qKey+" FROM "+qTable+where+" ORDER BY "+qKey, n, 0)
+	if query, err = forUpdateFmt(driver, query, RowLockOpts{SkipLocked: true}); err != nil {
+		return err
+	}
+	if query, err = statementFormat(query, argFmt, args...); err != nil {
+		return err
+	}
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	var keys []interface{}
+	for rows.Next() {
+		var k interface{}
+		if err := rows.Scan(&k); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	list := reflect.ValueOf(dest).Elem()
+	if len(keys) == 0 {
+		list.Set(reflect.MakeSlice(list.Type(), 0, 0))
+		return nil
+	}
+	sets := make([]string, len(cols))
+	for i, c := range cols {
+		sets[i] = quoteIdent(driver, c) + "=?"
+	}
+	in := make([]string, len(keys))
+	for i, k := range keys {
+		if in[i], err = argFmt(",", k); err != nil {
+			return err
+		}
+	}
+	inKeys := " WHERE " + qKey + " IN (" + strings.Join(in, ",") + ")"
+	update, err := statementFormat("UPDATE "+qTable+" SET "+strings.Join(sets, ", "), argFmt, vals...)
+	if err != nil {
+		return err
+	}
+	if _, err := q.ExecContext(ctx, update+inKeys); err != nil {
+		return err
+	}
+	rows, err = q.QueryContext(ctx, "SELECT * FROM "+qTable+inKeys+" ORDER BY "+qKey)
+	if err != nil {
+		return err
+	}
+	return checkAllV2(rows, dest)
+}
@@ -0,0 +1,114 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type queueJob struct {
+	ID     int64  `sql:"id,pk"`
+	State  string `sql:"state"`
+	Worker string `sql:"worker"`
+}
+
+func TestRowLockFmt(t *testing.T) {
+	cases := []struct {
+		driver dbDriver
+		opts   RowLockOpts
+		clause string
+		err    error
+	}{
+		{driverPostgresql, RowLockOpts{}, " FOR UPDATE", nil},
+		{driverMysql, RowLockOpts{SkipLocked: true}, " FOR UPDATE SKIP LOCKED", nil},
+		{driverPostgresql, RowLockOpts{Share: true, NoWait: true}, " FOR SHARE NOWAIT", nil},
+		{driverMysql, RowLockOpts{NoWait: true, SkipLocked: true}, "", ErrArgType},
+		{driverSqlite, RowLockOpts{}, "", ErrNotSupportForThisDriver},
+	}
+	for _, c := range cases {
+		clause, err := rowLockFmt(c.driver, c.opts)
+		if clause != c.clause || err != c.err {
+			t.Errorf("got %q %v, want %q %v", clause, err, c.clause, c.err)
+		}
+	}
+}
+
+func TestMysql_ClaimBatch(t *testing.T) {
+	cols := []fakeColumn{{name: "id", typeName: "BIGINT"}, {name: "state", typeName: "VARCHAR"}, {name: "worker", typeName: "VARCHAR"}}
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		switch {
+		case strings.HasPrefix(query, "SELECT `id` FROM"):
+			return &fakeResult{columns: cols[:1], rows: [][]driver.Value{{int64(3)}, {int64(5)}}}, nil
+		case strings.HasPrefix(query, "SELECT *"):
+			return &fakeResult{columns: cols, rows: [][]driver.Value{{int64(3), "running", "w1"}, {int64(5), "running", "w1"}}}, nil
+		}
+		return &fakeResult{aff: 2}, nil
+	})
+	ctx := context.TODO()
+	var jobs []*queueJob
+	err := db.ClaimBatch(ctx, 10, &jobs, "job", Expr("state = ?", "pending"),
+		map[string]interface{}{"state": "running", "worker": "w1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != 3 || jobs[1].Worker != "w1" {
+		t.Errorf("got %v", jobs)
+	}
+	cmp := []string{
+		"BEGIN",
+		"SELECT `id` FROM `job` WHERE state = 'pending' ORDER BY `id` LIMIT 10 FOR UPDATE SKIP LOCKED",
+		"UPDATE `job` SET `state`='running', `worker`='w1' WHERE `id` IN (3,5)",
+		"SELECT * FROM `job` WHERE `id` IN (3,5) ORDER BY `id`",
+		"COMMIT",
+	}
+	qs := fakeMysql.recorded()
+	if len(qs) != len(cmp) {
+		t.Fatalf("got %v", qs)
+	}
+	for i := range cmp {
+		if qs[i] != cmp[i] {
+			t.Errorf("got %s, want %s", qs[i], cmp[i])
+		}
+	}
+
+	// nothing to claim
+	fakeMysql.reset(nil)
+	if err := db.ClaimBatch(ctx, 10, &jobs, "job", Expr("state = ?", "pending"),
+		map[string]interface{}{"state": "running"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("got %v", jobs)
+	}
+
+	// condition with empty array does not claim all rows
+	fakeMysql.reset(nil)
+	err = db.ClaimBatch(ctx, 10, &jobs, "job", In("queue", []string{}), map[string]interface{}{"state": "running"})
+	if err != ErrEmptyArrayInStatement {
+		t.Errorf("expect ErrEmptyArrayInStatement, got %v", err)
+	}
+	if qs := fakeMysql.recorded(); len(qs) > 0 && qs[len(qs)-1] != "ROLLBACK" {
+		t.Errorf("got %v", qs)
+	}
+
+	// get for update in capsule
+	fakeMysql.reset(func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{columns: cols, rows: [][]driver.Value{{int64(3), "pending", ""}}}, nil
+	})
+	capsule := NewCapsule(db)
+	var job queueJob
+	if err := capsule.GetForUpdate(ctx, &job, "SELECT * FROM `job` WHERE `id` = ?", RowLockOpts{}, 3); err != ErrNoTransaction {
+		t.Errorf("expect ErrNoTransaction, got %v", err)
+	}
+	_, err = capsule.StartCapsule(ctx, true, func(ctx context.Context) (interface{}, error) {
+		return nil, capsule.GetForUpdate(ctx, &job, "SELECT * FROM `job` WHERE `id` = ?;", RowLockOpts{NoWait: true}, 3)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs = fakeMysql.recorded()
+	if job.ID != 3 || len(qs) != 3 || qs[1] != "SELECT * FROM `job` WHERE `id` = 3 FOR UPDATE NOWAIT" {
+		t.Errorf("got %v %v", job, qs)
+	}
+}
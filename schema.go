@@ -0,0 +1,297 @@
+package sqly
+
+import (
+	"context"
+	"strings"
+)
+
+// Table table or view of database
+type Table struct {
+	Name    string `sql:"name"`
+	Type    string `sql:"type"` // table or view
+	Comment string `sql:"comment"`
+}
+
+// Column column of table
+type Column struct {
+	Name          string     `sql:"name"`
+	Position      int        `sql:"position"`    // starts from 1
+	DataType      string     `sql:"data_type"`   // such as varchar, bigint, _int8 (array of postgresql)
+	ColumnType    string     `sql:"column_type"` // full type, such as varchar(32), int unsigned
+	Nullable      bool       `sql:"nullable"`
+	Default       NullString `sql:"default_value"` // expression of default value
+	PrimaryKey    bool       `sql:"primary_key"`
+	AutoIncrement bool       `sql:"auto_increment"` // auto increment, serial, identity or rowid of sqlite
+	Comment       string     `sql:"comment"`
+}
+
+// Index index of table
+type Index struct {
+	Name    string
+	Columns []string // columns or expressions in order
+	Unique  bool
+	Primary bool
+}
+
+// ForeignKey foreign key of table
+type ForeignKey struct {
+	Name       string // empty for sqlite
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string // such as NO ACTION, CASCADE, SET NULL
+	OnDelete   string
+}
+
+// one column of index
+type indexRow struct {
+	Name    string `sql:"name"`
+	Column  string `sql:"column_name"`
+	Unique  bool   `sql:"is_unique"`
+	Primary bool   `sql:"is_primary"`
+}
+
+// one column of foreign key
+type foreignKeyRow struct {
+	Name      string `sql:"name"`
+	Column    string `sql:"column_name"`
+	RefTable  string `sql:"ref_table"`
+	RefColumn string `sql:"ref_column"`
+	OnUpdate  string `sql:"on_update"`
+	OnDelete  string `sql:"on_delete"`
+}
+
+// queries of schema, tables of current schema (database), and columns, indexes, foreign keys of table
+type schemaQueries struct {
+	tables      string
+	columns     string
+	indexes     string
+	foreignKeys string
+}
+
+var schemaDialects = map[dbDriver]schemaQueries{
+	driverMysql: {
+		tables: "SELECT TABLE_NAME AS name, CASE TABLE_TYPE WHEN 'VIEW' THEN 'view' ELSE 'table' END AS type, " +
+			"COALESCE(TABLE_COMMENT, '') AS comment FROM information_schema.TABLES " +
+			"WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME",
+		columns: "SELECT COLUMN_NAME AS name, ORDINAL_POSITION AS position, DATA_TYPE AS data_type, " +
+			"COLUMN_TYPE AS column_type, IS_NULLABLE = 'YES' AS nullable, COLUMN_DEFAULT AS default_value, " +
+			"COLUMN_KEY = 'PRI' AS primary_key, EXTRA LIKE '%auto_increment%' AS auto_increment, " +
+			"COALESCE(COLUMN_COMMENT, '') AS comment FROM information_schema.COLUMNS " +
+			"WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+		indexes: "SELECT INDEX_NAME AS name, COALESCE(COLUMN_NAME, '') AS column_name, NON_UNIQUE = 0 AS is_unique, " +
+			"INDEX_NAME = 'PRIMARY' AS is_primary FROM information_schema.STATISTICS " +
+			"WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX",
+		foreignKeys: "SELECT k.CONSTRAINT_NAME AS name, k.COLUMN_NAME AS column_name, k.REFERENCED_TABLE_NAME AS ref_table, " +
+			"k.REFERENCED_COLUMN_NAME AS ref_column, r.UPDATE_RULE AS on_update, r.DELETE_RULE AS on_delete " +
+			"FROM information_schema.KEY_COLUMN_USAGE k JOIN information_schema.REFERENTIAL_CONSTRAINTS r " +
+			"ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME AND r.TABLE_NAME = k.TABLE_NAME " +
+			"WHERE k.TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND k.TABLE_NAME = ? " +
+			"AND k.REFERENCED_TABLE_NAME IS NOT NULL ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION",
+	},
+	driverPostgresql: {
+		tables: "SELECT c.relname AS name, CASE WHEN c.relkind IN ('v', 'm') THEN 'view' ELSE 'table' END AS type, " +
+			"COALESCE(obj_description(c.oid, 'pg_class'), '') AS comment FROM pg_catalog.pg_class c " +
+			"JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace " +
+			"WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p', 'v', 'm') ORDER BY c.relname",
+		columns: "SELECT a.attname AS name, a.attnum AS position, t.typname AS data_type, " +
+			"format_type(a.atttypid, a.atttypmod) AS column_type, NOT a.attnotnull AS nullable, " +
+			"pg_get_expr(d.adbin, d.adrelid) AS default_value, " +
+			"EXISTS (SELECT 1 FROM pg_catalog.pg_index i WHERE i.indrelid = c.oid AND i.indisprimary " +
+			"AND a.attnum = ANY(i.indkey)) AS primary_key, " +
+			"(a.attidentity <> '' OR COALESCE(pg_get_expr(d.adbin, d.adrelid), '') LIKE 'nextval(%') AS auto_increment, " +
+			"COALESCE(col_description(c.oid, a.attnum), '') AS comment FROM pg_catalog.pg_attribute a " +
+			"JOIN pg_catalog.pg_class c ON c.oid = a.attrelid JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace " +
+			"JOIN pg_catalog.pg_type t ON t.oid = a.atttypid " +
+			"LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum " +
+			"WHERE n.nspname = COALESCE(NULLIF(?, ''), current_schema()) AND c.relname = ? " +
+			"AND a.attnum > 0 AND NOT a.attisdropped ORDER BY a.attnum",
+		indexes: "SELECT ic.relname AS name, pg_get_indexdef(i.indexrelid, k.seq::int, true) AS column_name, " +
+			"i.indisunique AS is_unique, i.indisprimary AS is_primary FROM pg_catalog.pg_index i " +
+			"JOIN pg_catalog.pg_class c ON c.oid = i.indrelid JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace " +
+			"JOIN pg_catalog.pg_class ic ON ic.oid = i.indexrelid " +
+			"CROSS JOIN LATERAL unnest(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, seq) " +
+			"WHERE n.nspname = COALESCE(NULLIF(?, ''), current_schema()) AND c.relname = ? ORDER BY ic.relname, k.seq",
+		foreignKeys: "SELECT con.conname AS name, a.attname AS column_name, rc.relname AS ref_table, ra.attname AS ref_column, " +
+			pgFkAction("con.confupdtype") + " AS on_update, " + pgFkAction("con.confdeltype") + " AS on_delete " +
+			"FROM pg_catalog.pg_constraint con JOIN pg_catalog.pg_class c ON c.oid = con.conrelid " +
+			"JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace JOIN pg_catalog.pg_class rc ON rc.oid = con.confrelid " +
+			"CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refnum, seq) " +
+			"JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum " +
+			"JOIN pg_catalog.pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refnum " +
+			"WHERE con.contype = 'f' AND n.nspname = COALESCE(NULLIF(?, ''), current_schema()) AND c.relname = ? " +
+			"ORDER BY con.conname, k.seq",
+	},
+	driverSqlite: {
+		tables: "SELECT name, type, '' AS comment FROM sqlite_master " +
+			"WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name",
+		// PRAGMA table_info, data type and auto increment are resolved from type
+		columns: "SELECT name, cid + 1 AS position, type AS column_type, \"notnull\" = 0 AS nullable, " +
+			"dflt_value AS default_value, pk > 0 AS primary_key, '' AS comment FROM pragma_table_info(?) ORDER BY cid",
+		indexes: "SELECT il.name AS name, COALESCE(ii.name, '') AS column_name, il.\"unique\" AS is_unique, " +
+			"il.origin = 'pk' AS is_primary FROM pragma_index_list(?) AS il, pragma_index_info(il.name) AS ii " +
+			"ORDER BY il.name, ii.seqno",
+		// constraint name is not kept by sqlite, id of foreign key is used to group columns
+		foreignKeys: "SELECT CAST(id AS TEXT) AS name, \"from\" AS column_name, \"table\" AS ref_table, " +
+			"COALESCE(\"to\", '') AS ref_column, on_update, on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq",
+	},
+	driverMssql: {
+		tables: "SELECT t.TABLE_NAME AS name, CASE t.TABLE_TYPE WHEN 'VIEW' THEN 'view' ELSE 'table' END AS type, " +
+			"COALESCE(CAST(ep.value AS NVARCHAR(4000)), '') AS comment FROM INFORMATION_SCHEMA.TABLES t " +
+			"LEFT JOIN sys.extended_properties ep ON ep.major_id = OBJECT_ID(QUOTENAME(t.TABLE_SCHEMA) + '.' + QUOTENAME(t.TABLE_NAME)) " +
+			"AND ep.minor_id = 0 AND ep.name = 'MS_Description' WHERE t.TABLE_SCHEMA = SCHEMA_NAME() ORDER BY t.TABLE_NAME",
+		columns: "SELECT c.name AS name, c.column_id AS position, ty.name AS data_type, ty.name + CASE " +
+			"WHEN ty.name IN ('varchar', 'char', 'varbinary', 'binary') THEN " +
+			"'(' + CASE WHEN c.max_length = -1 THEN 'max' ELSE CAST(c.max_length AS VARCHAR(10)) END + ')' " +
+			"WHEN ty.name IN ('nvarchar', 'nchar') THEN " +
+			"'(' + CASE WHEN c.max_length = -1 THEN 'max' ELSE CAST(c.max_length / 2 AS VARCHAR(10)) END + ')' " +
+			"WHEN ty.name IN ('decimal', 'numeric') THEN " +
+			"'(' + CAST(c.precision AS VARCHAR(10)) + ',' + CAST(c.scale AS VARCHAR(10)) + ')' ELSE '' END AS column_type, " +
+			"c.is_nullable AS nullable, dc.definition AS default_value, " +
+			"CAST(CASE WHEN EXISTS (SELECT 1 FROM sys.indexes i JOIN sys.index_columns ic " +
+			"ON ic.object_id = i.object_id AND ic.index_id = i.index_id WHERE i.is_primary_key = 1 " +
+			"AND ic.object_id = c.object_id AND ic.column_id = c.column_id) THEN 1 ELSE 0 END AS BIT) AS primary_key, " +
+			"c.is_identity AS auto_increment, COALESCE(CAST(ep.value AS NVARCHAR(4000)), '') AS comment " +
+			"FROM sys.columns c JOIN sys.types ty ON ty.user_type_id = c.user_type_id " +
+			"LEFT JOIN sys.default_constraints dc ON dc.object_id = c.default_object_id " +
+			"LEFT JOIN sys.extended_properties ep ON ep.major_id = c.object_id AND ep.minor_id = c.column_id " +
+			"AND ep.name = 'MS_Description' WHERE c.object_id = OBJECT_ID(?) ORDER BY c.column_id",
+		indexes: "SELECT i.name AS name, COL_NAME(ic.object_id, ic.column_id) AS column_name, i.is_unique AS is_unique, " +
+			"i.is_primary_key AS is_primary FROM sys.indexes i JOIN sys.index_columns ic " +
+			"ON ic.object_id = i.object_id AND ic.index_id = i.index_id WHERE i.object_id = OBJECT_ID(?) " +
+			"AND ic.is_included_column = 0 ORDER BY i.name, ic.key_ordinal",
+		foreignKeys: "SELECT fk.name AS name, COL_NAME(fkc.parent_object_id, fkc.parent_column_id) AS column_name, " +
+			"OBJECT_NAME(fkc.referenced_object_id) AS ref_table, " +
+			"COL_NAME(fkc.referenced_object_id, fkc.referenced_column_id) AS ref_column, " +
+			"REPLACE(fk.update_referential_action_desc, '_', ' ') AS on_update, " +
+			"REPLACE(fk.delete_referential_action_desc, '_', ' ') AS on_delete FROM sys.foreign_keys fk " +
+			"JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id " +
+			"WHERE fk.parent_object_id = OBJECT_ID(?) ORDER BY fk.name, fkc.constraint_column_id",
+	},
+}
+
+// referential action of postgresql foreign key
+func pgFkAction(col string) string {
+	return "CASE " + col + " WHEN 'c' THEN 'CASCADE' WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' " +
+		"WHEN 'r' THEN 'RESTRICT' ELSE 'NO ACTION' END"
+}
+
+// queries of schema of driver
+func schemaOf(driver dbDriver) (schemaQueries, error) {
+	q, ok := schemaDialects[driver]
+	if !ok {
+		return schemaQueries{}, ErrNotSupportForThisDriver
+	}
+	return q, nil
+}
+
+// arguments of table, table can be qualified by schema (database), such as public.account,
+// mysql and postgresql take schema and name, current schema is used if schema is empty
+func tableArgs(driver dbDriver, table string) []interface{} {
+	if driver != driverMysql && driver != driverPostgresql {
+		return []interface{}{table}
+	}
+	schema := ""
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		schema, table = table[:i], table[i+1:]
+	}
+	return []interface{}{schema, table}
+}
+
+// tables of current schema
+func listTables(ctx context.Context, query queryFunc, driver dbDriver) ([]*Table, error) {
+	q, err := schemaOf(driver)
+	if err != nil {
+		return nil, err
+	}
+	var tables []*Table
+	if err := query(ctx, &tables, q.tables); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// columns of table in order
+func listColumns(ctx context.Context, query queryFunc, driver dbDriver, table string) ([]*Column, error) {
+	q, err := schemaOf(driver)
+	if err != nil {
+		return nil, err
+	}
+	var cols []*Column
+	if err := query(ctx, &cols, q.columns, tableArgs(driver, table)...); err != nil {
+		return nil, err
+	}
+	if driver == driverSqlite {
+		sqliteColumns(cols)
+	}
+	return cols, nil
+}
+
+// resolve data type from declared type, and INTEGER PRIMARY KEY is alias of rowid
+func sqliteColumns(cols []*Column) {
+	pks := 0
+	for _, c := range cols {
+		c.DataType = strings.ToLower(strings.TrimSpace(c.ColumnType))
+		if i := strings.IndexByte(c.DataType, '('); i >= 0 {
+			c.DataType = strings.TrimSpace(c.DataType[:i])
+		}
+		if c.PrimaryKey {
+			pks++
+		}
+	}
+	for _, c := range cols {
+		if c.PrimaryKey && pks == 1 && c.DataType == "integer" {
+			c.AutoIncrement = true
+			c.Nullable = false
+		}
+	}
+}
+
+// indexes of table, ordered by name
+func listIndexes(ctx context.Context, query queryFunc, driver dbDriver, table string) ([]*Index, error) {
+	q, err := schemaOf(driver)
+	if err != nil {
+		return nil, err
+	}
+	var rows []*indexRow
+	if err := query(ctx, &rows, q.indexes, tableArgs(driver, table)...); err != nil {
+		return nil, err
+	}
+	var indexes []*Index
+	for _, r := range rows {
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != r.Name {
+			indexes = append(indexes, &Index{Name: r.Name, Unique: r.Unique, Primary: r.Primary})
+		}
+		idx := indexes[len(indexes)-1]
+		idx.Columns = append(idx.Columns, r.Column)
+	}
+	return indexes, nil
+}
+
+// foreign keys of table, ordered by name
+func listForeignKeys(ctx context.Context, query queryFunc, driver dbDriver, table string) ([]*ForeignKey, error) {
+	q, err := schemaOf(driver)
+	if err != nil {
+		return nil, err
+	}
+	var rows []*foreignKeyRow
+	if err := query(ctx, &rows, q.foreignKeys, tableArgs(driver, table)...); err != nil {
+		return nil, err
+	}
+	var fks []*ForeignKey
+	last := ""
+	for _, r := range rows {
+		if len(fks) == 0 || last != r.Name {
+			fk := &ForeignKey{Name: r.Name, RefTable: r.RefTable, OnUpdate: r.OnUpdate, OnDelete: r.OnDelete}
+			if driver == driverSqlite {
+				fk.Name = ""
+			}
+			fks = append(fks, fk)
+			last = r.Name
+		}
+		fk := fks[len(fks)-1]
+		fk.Columns = append(fk.Columns, r.Column)
+		fk.RefColumns = append(fk.RefColumns, r.RefColumn)
+	}
+	return fks, nil
+}
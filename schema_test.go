@@ -0,0 +1,102 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSqlite_Schema(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+	err := db.ExecMany([]string{
+		"DROP VIEW IF EXISTS `valid_account`",
+		"DROP TABLE IF EXISTS `order`",
+		"CREATE TABLE `order` (`id` INTEGER PRIMARY KEY, `account_id` INTEGER NOT NULL, `no` VARCHAR(32) NOT NULL, " +
+			"`amount` DECIMAL(10, 2) DEFAULT 0, FOREIGN KEY (`account_id`) REFERENCES `account` (`id`) ON DELETE CASCADE)",
+		"CREATE UNIQUE INDEX `uk_order` ON `order` (`account_id`, `no`)",
+		"CREATE VIEW `valid_account` AS SELECT * FROM `account` WHERE `is_valid` = 1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.ExecMany([]string{"DROP VIEW `valid_account`", "DROP TABLE `order`"})
+	}()
+
+	tables, err := db.Tables(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tb := range tables {
+		names = append(names, tb.Name+":"+tb.Type)
+	}
+	if !reflect.DeepEqual(names, []string{"account:table", "order:table", "valid_account:view"}) {
+		t.Errorf("got %v", names)
+	}
+
+	cols, err := db.Columns(ctx, "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 4 {
+		t.Fatalf("got %d columns", len(cols))
+	}
+	id, amount := cols[0], cols[3]
+	if id.Name != "id" || id.Position != 1 || !id.PrimaryKey || !id.AutoIncrement || id.Nullable || id.DataType != "integer" {
+		t.Errorf("got %+v", id)
+	}
+	if amount.DataType != "decimal" || amount.ColumnType != "DECIMAL(10, 2)" || !amount.Nullable ||
+		amount.Default.String != "0" || cols[2].Nullable {
+		t.Errorf("got %+v %+v", amount, cols[2])
+	}
+
+	indexes, err := db.Indexes(ctx, "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexes) != 1 || indexes[0].Name != "uk_order" || !indexes[0].Unique || indexes[0].Primary ||
+		!reflect.DeepEqual(indexes[0].Columns, []string{"account_id", "no"}) {
+		t.Errorf("got %+v", indexes)
+	}
+
+	fks, err := db.ForeignKeys(ctx, "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fks) != 1 || fks[0].RefTable != "account" || fks[0].Columns[0] != "account_id" ||
+		fks[0].RefColumns[0] != "id" || fks[0].OnDelete != "CASCADE" || fks[0].OnUpdate != "NO ACTION" {
+		t.Errorf("got %+v", fks)
+	}
+}
+
+func TestPostgres_Schema(t *testing.T) {
+	db := newFakeDb(t, fakePostgres, "fake_postgres", driverPostgresql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{
+			columns: []fakeColumn{{name: "name"}, {name: "column_name"}, {name: "is_unique"}, {name: "is_primary"}},
+			rows: [][]driver.Value{
+				{"account_pkey", "id", true, true},
+				{"idx_name", "lower(nickname)", false, false},
+				{"idx_name", "mobile", false, false},
+			},
+		}, nil
+	})
+	indexes, err := db.Indexes(context.TODO(), "app.account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexes) != 2 || !indexes[0].Primary || !reflect.DeepEqual(indexes[1].Columns, []string{"lower(nickname)", "mobile"}) {
+		t.Errorf("got %+v", indexes)
+	}
+	qs := fakePostgres.recorded()
+	if len(qs) != 1 || !strings.Contains(qs[0], "n.nspname = COALESCE(NULLIF(E'app', ''), current_schema()) AND c.relname = E'account'") {
+		t.Errorf("got %v", qs)
+	}
+	if _, err := newFakeDb(t, fakeClickhouse, "clickhouse", driverClickhouse, nil).Tables(context.TODO()); err != ErrNotSupportForThisDriver {
+		t.Errorf("expect ErrNotSupportForThisDriver, got %v", err)
+	}
+}
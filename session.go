@@ -0,0 +1,190 @@
+package sqly
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Session statements executed on one dedicated connection, which keeps session state,
+// such as session variables and temporary tables
+type Session struct {
+	conn   *sql.Conn
+	driver dbDriver
+	sqlY   *SqlY
+}
+
+// Session run fn on one connection pinned from pool,
+// session state of postgresql is reset before the connection is returned to pool,
+// the connection of mysql is closed instead, as its temporary tables and variables can't be reset,
+// and so is the connection that fails to reset
+func (s *SqlY) Session(ctx context.Context, fn func(sess *Session) error) (err error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	sess := &Session{conn: conn, driver: s.driver, sqlY: s}
+	defer func() {
+		rErr := sess.reset()
+		if rErr != nil || s.driver == driverMysql {
+			discardConn(conn)
+		}
+		if rErr != nil && err == nil {
+			err = rErr
+		}
+	}()
+	return fn(sess)
+}
+
+// discard the connection instead of returning it to pool, as its session state is unknown
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+}
+
+// Conn the pinned connection
+func (ss *Session) Conn() *sql.Conn {
+	return ss.conn
+}
+
+// Set set session variable, such as Set(ctx, "time_zone", "+00:00") of mysql,
+// Set(ctx, "search_path", []string{"app", "public"}) of postgresql, value of slice is set as a list
+func (ss *Session) Set(ctx context.Context, name string, value interface{}) error {
+	if ss.driver != driverPostgresql && ss.driver != driverMysql {
+		return ErrNotSupportForThisDriver
+	}
+	var vals []string
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < v.Len(); i++ {
+			s, err := ss.sqlY.argFmt(",", v.Index(i).Interface())
+			if err != nil {
+				return err
+			}
+			vals = append(vals, s)
+		}
+	} else {
+		s, err := ss.sqlY.argFmt(",", value)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, s)
+	}
+	_, err := ss.conn.ExecContext(ctx, "SET "+name+" = "+strings.Join(vals, ", "))
+	return err
+}
+
+// reset session state before the connection is returned to pool,
+// postgresql resets all variables and drops temporary tables
+func (ss *Session) reset() error {
+	if ss.driver != driverPostgresql {
+		return nil
+	}
+	ctx := context.Background()
+	for _, query := range []string{"RESET ALL", "DISCARD TEMP"} {
+		if _, err := ss.conn.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query query rows to dest
+func (ss *Session) Query(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	q, err := statementFormat(query, ss.sqlY.argFmt, args...)
+	if err != nil {
+		if errors.Is(err, ErrEmptyArrayInStatement) {
+			return nil
+		}
+		return err
+	}
+	rows, err := ss.conn.QueryContext(ctx, q)
+	if err != nil {
+		return err
+	}
+	return checkAllV2(rows, dest)
+}
+
+// Get query one row to dest
+func (ss *Session) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	q, err := statementFormat(query, ss.sqlY.argFmt, args...)
+	if err != nil {
+		if errors.Is(err, ErrEmptyArrayInStatement) {
+			return nil
+		}
+		return err
+	}
+	rows, err := ss.conn.QueryContext(ctx, q)
+	if err != nil {
+		return err
+	}
+	return checkOneV2(rows, dest)
+}
+
+// Exec general sql statement execute
+func (ss *Session) Exec(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
+	q, err := statementFormat(query, ss.sqlY.argFmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := ss.conn.ExecContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return &Affected{result: res, driver: ss.driver}, nil
+}
+
+// Insert insert
+func (ss *Session) Insert(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
+	return ss.Exec(ctx, query, args...)
+}
+
+// InsertMany insert many rows, split into chunks as SqlY.InsertManyCtx
+func (ss *Session) InsertMany(ctx context.Context, query string, args [][]interface{}) (*Affected, error) {
+	if ss.driver == driverClickhouse {
+		return execBatchDb(ctx, ss.conn, ss.driver, query, args)
+	}
+	qs, err := multiRowsChunkFmt(ss.driver, query, ss.sqlY.argFmt, args, ss.sqlY.chunkRows, ss.sqlY.chunkBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(qs) == 1 {
+		return ss.Exec(ctx, qs[0])
+	}
+	return execChunksDb(ctx, ss.conn, ss.driver, qs)
+}
+
+// Update update
+func (ss *Session) Update(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
+	return ss.Exec(ctx, query, args...)
+}
+
+// Delete delete
+func (ss *Session) Delete(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
+	return ss.Exec(ctx, query, args...)
+}
+
+// Transaction start transaction on the connection of session with callback function
+func (ss *Session) Transaction(ctx context.Context, txFunc TxFunc) (interface{}, error) {
+	tx, err := ss.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	res, err := txFunc(&Trans{tx: tx, driver: ss.driver, sqlY: ss.sqlY})
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
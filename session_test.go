@@ -0,0 +1,123 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestMysql_Session(t *testing.T) {
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, nil)
+	defer db.Close()
+	ctx := context.TODO()
+	err := db.Session(ctx, func(sess *Session) error {
+		if err := sess.Set(ctx, "time_zone", "+00:00"); err != nil {
+			return err
+		}
+		if err := sess.Set(ctx, "@tenant", 7); err != nil {
+			return err
+		}
+		if _, err := sess.Exec(ctx, "CREATE TEMPORARY TABLE `import` LIKE `account`"); err != nil {
+			return err
+		}
+		_, err := sess.Update(ctx, "UPDATE `account` SET `role`=? WHERE `tenant`=@tenant", 2)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp := []string{
+		"SET time_zone = '+00:00'",
+		"SET @tenant = 7",
+		"CREATE TEMPORARY TABLE `import` LIKE `account`",
+		"UPDATE `account` SET `role`=2 WHERE `tenant`=@tenant",
+	}
+	qs := fakeMysql.recorded()
+	if len(qs) != len(cmp) {
+		t.Fatalf("got %v", qs)
+	}
+	for i := range cmp {
+		if qs[i] != cmp[i] {
+			t.Errorf("got %s, want %s", qs[i], cmp[i])
+		}
+	}
+	// the connection with session state is not returned to pool
+	if stats := db.Stats(); stats.OpenConnections != 0 || stats.Idle != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPostgres_SessionResetFailed(t *testing.T) {
+	db := newFakeDb(t, fakePostgres, "fake_postgres", driverPostgresql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if query == "RESET ALL" {
+			return nil, errors.New("connection lost")
+		}
+		return &fakeResult{}, nil
+	})
+	defer db.Close()
+	ctx := context.TODO()
+	err := db.Session(ctx, func(sess *Session) error {
+		return sess.Set(ctx, "search_path", "app")
+	})
+	if err == nil || err.Error() != "connection lost" {
+		t.Errorf("expect reset error, got %v", err)
+	}
+	// the connection is not returned to pool
+	if stats := db.Stats(); stats.OpenConnections != 0 || stats.Idle != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPostgres_Session(t *testing.T) {
+	db := newFakeDb(t, fakePostgres, "fake_postgres", driverPostgresql, nil)
+	ctx := context.TODO()
+	err := db.Session(ctx, func(sess *Session) error {
+		return sess.Set(ctx, "search_path", []string{"app", "public"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := fakePostgres.recorded()
+	if len(qs) != 3 || qs[0] != "SET search_path = E'app', E'public'" || qs[1] != "RESET ALL" || qs[2] != "DISCARD TEMP" {
+		t.Errorf("got %v", qs)
+	}
+	// the connection is reset and returned to pool
+	if stats := db.Stats(); stats.Idle != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestSqlite_Session(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+	var accs []*Account
+	err := db.Session(ctx, func(sess *Session) error {
+		if _, err := sess.Exec(ctx, "CREATE TEMP TABLE `import` (`nickname` VARCHAR(32), `mobile` VARCHAR(16))"); err != nil {
+			return err
+		}
+		_, err := sess.InsertMany(ctx, "INSERT INTO `import` (`nickname`, `mobile`) VALUES (?, ?)", [][]interface{}{
+			{"lucy", "18812311231"}, {"lily", "18812311232"},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = sess.Transaction(ctx, func(tx *Trans) (interface{}, error) {
+			return tx.Exec("INSERT INTO `account` (`nickname`, `mobile`) SELECT `nickname`, `mobile` FROM `import`")
+		})
+		if err != nil {
+			return err
+		}
+		if err := sess.Set(ctx, "foo", 1); err != ErrNotSupportForThisDriver {
+			t.Errorf("expect ErrNotSupportForThisDriver, got %v", err)
+		}
+		return sess.Query(ctx, &accs, "SELECT * FROM `account` WHERE `mobile` IN ?", []string{"18812311231", "18812311232"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accs) != 2 {
+		t.Errorf("got %d accounts", len(accs))
+	}
+}
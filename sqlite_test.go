@@ -0,0 +1,493 @@
+package sqly
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var sqliteOpt = &Option{
+	Dsn:             "file::memory:?cache=shared",
+	DriverName:      "sqlite3",
+	MaxIdleConns:    1,
+	MaxOpenConns:    1,
+	ConnMaxLifeTime: 0,
+}
+
+// open an in-memory sqlite database with an empty account table
+func newSqliteDb(t *testing.T) *SqlY {
+	db, err := New(sqliteOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ExecMany(sqliteAccountTable); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// account table of sqlite
+var sqliteAccountTable = []string{
+	"DROP TABLE IF EXISTS `account`;",
+	"CREATE TABLE `account` (" +
+		"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+		"`nickname` VARCHAR(32) NOT NULL," +
+		"`avatar` VARCHAR(200) DEFAULT NULL," +
+		"`mobile` VARCHAR(16) NOT NULL UNIQUE," +
+		"`email` VARCHAR(320) NOT NULL DEFAULT ''," +
+		"`password` VARCHAR(64) NOT NULL DEFAULT ''," +
+		"`role` TINYINT DEFAULT 0," +
+		"`tags` VARCHAR(320)," +
+		"`is_valid` BOOLEAN DEFAULT NULL," +
+		"`stature` REAL DEFAULT NULL," +
+		"`create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+		"`add_time` DATETIME DEFAULT NULL," +
+		"`birthday` DATE DEFAULT NULL" +
+		");",
+}
+
+func TestQueryFmtSqlite(t *testing.T) {
+	query := "INSERT INTO `account` (`nickname`, `mobile`, `is_valid`, `avatar`) VALUES (?, ?, ?, ?)"
+	res, err := QueryFmtSqlite(query, "it's \\n", "18812311231", true, []byte{0x01, 0xab})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resCmp := "INSERT INTO `account` (`nickname`, `mobile`, `is_valid`, `avatar`) VALUES ('it''s \\n', '18812311231', 1, X'01ab')"
+	if res != resCmp {
+		t.Errorf("got %s", res)
+	}
+
+	tm := time.Date(2021, 5, 1, 8, 30, 0, 0, time.UTC)
+	res, err = QueryFmtSqlite("SELECT * FROM `account` WHERE `add_time`=? AND `mobile` IN ?", tm, []string{"a'b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resCmp = "SELECT * FROM `account` WHERE `add_time`='2021-05-01 08:30:00+00:00' AND `mobile` IN ('a''b','c')"
+	if res != resCmp {
+		t.Errorf("got %s", res)
+	}
+}
+
+func TestSqlite_Insert(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+
+	query := "INSERT INTO `account` (`nickname`, `mobile`, `email`, `role`, `tags`, `is_valid`, `add_time`) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?);"
+	aff, err := db.Insert(query, "nick'test", "18812311231", "test@foxmail.com", 1,
+		Array([]string{"tag1", "tag2"}), true, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := aff.GetLastId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 {
+		t.Errorf("last id %d", id)
+	}
+
+	var acc Account
+	if err := db.Get(&acc, "SELECT * FROM `account` WHERE `id`=?", id); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Nickname != "nick'test" || !acc.IsValid.Bool || !acc.AddTime.Valid || len(acc.Tags) != 2 {
+		t.Errorf("unexpected account %+v", acc)
+	}
+}
+
+func TestSqlite_InsertMany(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+
+	query := "INSERT INTO `account` (`nickname`, `mobile`, `email`, `role`) VALUES (?, ?, ?, ?);"
+	vals := [][]interface{}{
+		{"nick1", "18812311231", "t1@qq.com", 1},
+		{"nick2", "18812311232", "t2@qq.com", 2},
+		{"nick3", "18812311233", "t3@qq.com", NullInt32{}},
+	}
+	aff, err := db.InsertMany(query, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := aff.GetRowsAffected()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 3 {
+		t.Errorf("rows affected %d", rows)
+	}
+
+	var accs []*Account
+	if err := db.Query(&accs, "SELECT * FROM `account` WHERE `mobile` IN ? ORDER BY `id`",
+		[]string{"18812311231", "18812311233"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(accs) != 2 || accs[1].Role.Valid {
+		t.Errorf("unexpected accounts %+v", accs)
+	}
+
+	var ms []map[string]interface{}
+	if err := db.Query(&ms, "SELECT `id`, `nickname`, `stature` FROM `account`"); err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 3 {
+		t.Fatalf("got %d rows", len(ms))
+	}
+	if _, ok := ms[0]["nickname"].(*NullString); !ok {
+		t.Errorf("unexpected type %T for varchar", ms[0]["nickname"])
+	}
+	if _, ok := ms[0]["stature"].(*NullFloat64); !ok {
+		t.Errorf("unexpected type %T for real", ms[0]["stature"])
+	}
+
+	if err := db.ExecMany([]string{"CREATE TEMP TABLE `flag` (`ok` BOOL NOT NULL)", "INSERT INTO `flag` VALUES (1)"}); err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[string]interface{})
+	if err := db.Get(&m, "SELECT `ok` FROM `flag`"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := m["ok"].(*NullBool); !ok || !v.Bool {
+		t.Errorf("unexpected %T %v for bool", m["ok"], m["ok"])
+	}
+}
+
+func TestSqlite_PgExec(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+
+	query := "INSERT INTO `account` (`nickname`, `mobile`) VALUES (?, ?)"
+	aff, err := db.PgExec("id", query, "nick1", "18812311231")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := aff.GetLastId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 {
+		t.Errorf("last id %d", id)
+	}
+}
+
+func TestSqlite_Transaction(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+
+	_, err := db.Transaction(func(tx *Trans) (interface{}, error) {
+		_, err := tx.Insert("INSERT INTO `account` (`nickname`, `mobile`) VALUES (?, ?)", "nick1", "18812311231")
+		if err != nil {
+			return nil, err
+		}
+		return tx.Update("UPDATE `account` SET `nickname`=? WHERE `mobile`=?", "lucy", "18812311231")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	capsule := NewCapsule(db)
+	_, err = capsule.StartCapsule(context.TODO(), true, func(ctx context.Context) (interface{}, error) {
+		var nickname string
+		if err := capsule.Get(ctx, &nickname, "SELECT `nickname` FROM `account` WHERE `mobile`=?", "18812311231"); err != nil {
+			return nil, err
+		}
+		if nickname != "lucy" {
+			t.Errorf("nickname %s", nickname)
+		}
+		return capsule.Delete(ctx, "DELETE FROM `account` WHERE `mobile`=?", "18812311231")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSqlite_ExecReturning(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+
+	var acc Account
+	err := db.ExecReturning(ctx, &acc, "INSERT INTO `account` (`nickname`, `mobile`) VALUES (?, ?);", "nick1", "18812311231")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.ID != 1 || acc.Nickname != "nick1" || acc.CreateTime.IsZero() {
+		t.Errorf("unexpected account %+v", acc)
+	}
+
+	_, err = db.Transaction(func(tx *Trans) (interface{}, error) {
+		var ids []int64
+		query := "INSERT INTO `account` (`nickname`, `mobile`) VALUES (?, ?), (?, ?) RETURNING `id`"
+		if err := tx.ExecReturning(ctx, &ids, query, "nick2", "18812311232", "nick3", "18812311233"); err != nil {
+			return nil, err
+		}
+		if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+			t.Errorf("unexpected ids %v", ids)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	capsule := NewCapsule(db)
+	res := make(map[string]interface{})
+	query := "UPDATE `account` SET `role`=? WHERE `id`=? RETURNING `id`, `role`"
+	if err := capsule.ExecReturning(ctx, &res, query, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := res["role"].(*NullInt32); !ok || v.Int32 != 2 {
+		t.Errorf("unexpected role %v", res["role"])
+	}
+}
+
+func TestSqlite_InsertManyReturningIDs(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+
+	query := "INSERT INTO `account` (`nickname`, `mobile`) VALUES (?, ?)"
+	ids, err := db.InsertManyReturningIDs(ctx, "id", query, [][]interface{}{
+		{"nick1", "18812311231"}, {"nick2", "18812311232"}, {"nick3", "18812311233"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+		t.Errorf("unexpected ids %v", ids)
+	}
+
+	capsule := NewCapsule(db)
+	_, err = capsule.StartCapsule(ctx, true, func(ctx context.Context) (interface{}, error) {
+		ids, err := capsule.InsertManyReturningIDs(ctx, "id", query, [][]interface{}{
+			{"nick4", "18812311234"}, {"nick5", "18812311235"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) != 2 || ids[0] != 4 || ids[1] != 5 {
+			t.Errorf("unexpected ids %v", ids)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSqlite_InsertManyChunks(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	db.chunkRows = 2
+
+	query := "INSERT INTO `account` (`nickname`, `mobile`) VALUES (?, ?)"
+	var vals [][]interface{}
+	for i := 0; i < 5; i++ {
+		vals = append(vals, []interface{}{"nick", "1881231123" + string(rune('0'+i))})
+	}
+	aff, err := db.InsertMany(query, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := aff.GetRowsAffected()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 5 {
+		t.Errorf("rows affected %d", rows)
+	}
+
+	// the chunks are rolled back together
+	vals[4] = []interface{}{"nick", "18812311230"}
+	for i := 0; i < 4; i++ {
+		vals[i] = []interface{}{"nick", "1881231124" + string(rune('0'+i))}
+	}
+	if _, err := db.InsertMany(query, vals); err == nil {
+		t.Fatal("expect unique constraint error")
+	}
+	var count int64
+	if err := db.Get(&count, "SELECT COUNT(*) FROM `account`"); err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Errorf("count %d", count)
+	}
+}
+
+func TestSqlite_UpdateMany(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+
+	query := "INSERT INTO `account` (`nickname`, `mobile`, `role`) VALUES (?, ?, ?)"
+	_, err := db.InsertMany(query, [][]interface{}{
+		{"nick1", "18812311231", 1}, {"nick2", "18812311232", 1}, {"nick3", "18812311233", 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query = "UPDATE `account` SET `nickname`=?, `avatar`=? WHERE `role`=? AND `mobile`<>'?'"
+	aff, err := db.UpdateMany(query, [][]interface{}{
+		{"lucy", NullString{String: "a.png", Valid: true}, 1},
+		{"lily", NullString{}, 2},
+		{"lucas", NullString{}, 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, err := aff.GetRowsAffected()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := aff.GetRowsAffectedList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(list) != 3 || list[0] != 2 || list[1] != 1 || list[2] != 0 {
+		t.Errorf("unexpected rows affected %d %v", total, list)
+	}
+
+	var acc Account
+	if err := db.Get(&acc, "SELECT * FROM `account` WHERE `mobile`=?", "18812311232"); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Nickname != "lucy" || acc.Avatar.String != "a.png" {
+		t.Errorf("unexpected account %+v", acc)
+	}
+
+	_, err = db.Transaction(func(tx *Trans) (interface{}, error) {
+		return tx.UpdateMany("UPDATE `account` SET `is_valid`=? WHERE `id`=?", [][]interface{}{
+			{Boolean(true), 1}, {NullBool{}, 2},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rows with slice argument are formatted, others are bound to prepared statement
+	aff, err = db.UpdateMany("UPDATE `account` SET `role`=? WHERE `id` IN ?", [][]interface{}{
+		{3, []int64{1, 2}}, {4, []int64{3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := aff.GetRowsAffectedList(); len(list) != 2 || list[0] != 2 || list[1] != 1 {
+		t.Errorf("unexpected rows affected %v", list)
+	}
+	var roles []int64
+	if err := db.Query(&roles, "SELECT `role` FROM `account` ORDER BY `id`"); err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 3 || roles[0] != 3 || roles[1] != 3 || roles[2] != 4 {
+		t.Errorf("unexpected roles %v", roles)
+	}
+}
+
+func TestSqlite_Upsert(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+
+	type account struct {
+		Nickname string     `sql:"nickname"`
+		Mobile   string     `sql:"mobile"`
+		Role     NullInt32  `sql:"role"`
+		Avatar   NullString `sql:"avatar"`
+	}
+	acc := &account{Nickname: "lucy", Mobile: "18812311231", Role: NullInt32{Int32: 1, Valid: true}}
+	if _, err := db.Upsert(ctx, "account", []string{"mobile"}, acc); err != nil {
+		t.Fatal(err)
+	}
+	acc.Nickname = "lily"
+	acc.Role = NullInt32{Int32: 2, Valid: true}
+	// only nickname is updated
+	if _, err := db.Upsert(ctx, "account", []string{"mobile"}, acc, "nickname"); err != nil {
+		t.Fatal(err)
+	}
+	var res Account
+	if err := db.Get(&res, "SELECT * FROM `account` WHERE `mobile`=?", "18812311231"); err != nil {
+		t.Fatal(err)
+	}
+	if res.Nickname != "lily" || res.Role.Int32 != 1 {
+		t.Errorf("unexpected account %+v", res)
+	}
+
+	capsule := NewCapsule(db)
+	_, err := capsule.StartCapsule(ctx, true, func(ctx context.Context) (interface{}, error) {
+		return capsule.UpsertMany(ctx, "account", []string{"mobile"}, []map[string]interface{}{
+			{"nickname": "lucas", "mobile": "18812311231"},
+			{"nickname": "lucy", "mobile": "18812311232"},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nicknames []string
+	if err := db.Query(&nicknames, "SELECT `nickname` FROM `account` ORDER BY `id`"); err != nil {
+		t.Fatal(err)
+	}
+	if len(nicknames) != 2 || nicknames[0] != "lucas" || nicknames[1] != "lucy" {
+		t.Errorf("unexpected nicknames %v", nicknames)
+	}
+}
+
+func TestSqlite_StructCRUD(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	ctx := context.TODO()
+
+	type account struct {
+		ID       int64     `sql:"id,pk"`
+		Nickname string    `sql:"nickname"`
+		Mobile   string    `sql:"mobile"`
+		Role     NullInt32 `sql:"role"`
+	}
+	acc := &account{Nickname: "lucy", Mobile: "18812311231"}
+	if _, err := db.InsertStruct(ctx, "account", acc); err != nil {
+		t.Fatal(err)
+	}
+	if acc.ID != 1 {
+		t.Errorf("generated id is not set, got %d", acc.ID)
+	}
+	acc.Nickname = "lily"
+	acc.Role = NullInt32{Int32: 2, Valid: true}
+	aff, err := db.UpdateStruct(ctx, "account", acc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := aff.GetRowsAffected(); n != 1 {
+		t.Errorf("rows affected %d", n)
+	}
+	res := new(account)
+	if err := db.Get(res, "SELECT `id`, `nickname`, `mobile`, `role` FROM `account` WHERE `id`=?", acc.ID); err != nil {
+		t.Fatal(err)
+	}
+	if *res != *acc {
+		t.Errorf("got %+v, want %+v", res, acc)
+	}
+
+	capsule := NewCapsule(db)
+	_, err = capsule.StartCapsule(ctx, true, func(ctx context.Context) (interface{}, error) {
+		return capsule.DeleteByPK(ctx, "account", acc)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := db.Get(&count, "SELECT COUNT(*) FROM `account`"); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("row is not deleted")
+	}
+
+	type noPk struct {
+		Nickname string `sql:"nickname"`
+	}
+	if _, err := db.DeleteByPK(ctx, "account", &noPk{}); err != ErrNoPrimaryKey {
+		t.Errorf("expect ErrNoPrimaryKey, got %v", err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"time"
 )
 
@@ -13,33 +12,74 @@ type dbDriver int8
 const (
 	driverMysql      dbDriver = 1
 	driverPostgresql dbDriver = 2
+	driverSqlite     dbDriver = 3
+	driverMssql      dbDriver = 4
+	driverClickhouse dbDriver = 5
 	driverOthers     dbDriver = 99
 )
 
-var argFmtFunc argFormat
-
 // SqlY struct
 type SqlY struct {
-	db     *sql.DB
-	driver dbDriver
+	db         *sql.DB
+	driver     dbDriver
+	server     *serverInfo
+	chunkRows  int       // max rows of each insert statement of InsertMany
+	chunkBytes int       // max length of each insert statement of InsertMany
+	argFmt     argFormat // formats arguments into statement by dialect
+	stmts      *stmtCache
 }
 
+// defaultChunkBytes default max length of insert statement, below the default max_allowed_packet(4MB) of mysql 5.7
+const defaultChunkBytes = 4<<20 - 1024
+
+// mssqlMaxRows max rows of each insert statement of sql server, which rejects more than 1000 row value expressions
+const mssqlMaxRows = 1000
+
 // Option sqly config option
 type Option struct {
-	Dsn             string        `json:"dsn"`                // database server name
-	DriverName      string        `json:"driver_name"`        // database driver
-	MaxIdleConns    int           `json:"max_idle_conns"`     // limit the number of idle connections
-	MaxOpenConns    int           `json:"max_open_conns"`     // limit the number of total open connections
-	ConnMaxLifeTime time.Duration `json:"conn_max_life_time"` // maximum amount of time a connection may be reused
+	Dsn               string        `json:"dsn"`                 // database server name
+	DriverName        string        `json:"driver_name"`         // database driver
+	MaxIdleConns      int           `json:"max_idle_conns"`      // limit the number of idle connections
+	MaxOpenConns      int           `json:"max_open_conns"`      // limit the number of total open connections
+	ConnMaxLifeTime   time.Duration `json:"conn_max_life_time"`  // maximum amount of time a connection may be reused
+	ConnMaxIdleTime   time.Duration `json:"conn_max_idle_time"`  // maximum amount of time a connection may be idle
+	ConnTimeout       time.Duration `json:"conn_timeout"`        // timeout of each ping on startup, 0 means no timeout
+	ConnRetries       int           `json:"conn_retries"`        // times to retry the ping on startup if it failed
+	ConnRetryInterval time.Duration `json:"conn_retry_interval"` // interval between two ping retries
+	InsertChunkRows   int           `json:"insert_chunk_rows"`   // max rows of each insert statement of InsertMany, 0 means no limit
+	InsertChunkBytes  int           `json:"insert_chunk_bytes"`  // max length of each insert statement of InsertMany, 0 means 4MB, -1 means no limit
+	StmtCacheSize     int           `json:"stmt_cache_size"`     // max number of cached prepared statements, 0 means no cache
+}
+
+// ping database, retry if failed
+func ping(db *sql.DB, opt *Option) error {
+	var err error
+	for i := 0; i <= opt.ConnRetries; i++ {
+		if i > 0 && opt.ConnRetryInterval > 0 {
+			time.Sleep(opt.ConnRetryInterval)
+		}
+		ctx := context.Background()
+		cancel := func() {}
+		if opt.ConnTimeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, opt.ConnTimeout)
+		}
+		err = db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return err
 }
 
 // connect to database
-func conn(driverName, dsn string) (*sql.DB, error) {
-	db, err := sql.Open(driverName, dsn)
+func conn(opt *Option) (*sql.DB, error) {
+	db, err := sql.Open(opt.DriverName, opt.Dsn)
 	if err != nil {
 		return nil, err
 	}
-	if err = db.Ping(); err != nil {
+	if err = ping(db, opt); err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 	return db, nil
@@ -47,27 +87,82 @@ func conn(driverName, dsn string) (*sql.DB, error) {
 
 // New init SqlY to database
 func New(opt *Option) (*SqlY, error) {
-	db, err := conn(opt.DriverName, opt.Dsn)
+	db, err := conn(opt)
 	if err != nil {
 		return nil, err
 	}
 	db.SetConnMaxLifetime(opt.ConnMaxLifeTime)
+	db.SetConnMaxIdleTime(opt.ConnMaxIdleTime)
 	db.SetMaxIdleConns(opt.MaxIdleConns)
 	db.SetMaxOpenConns(opt.MaxOpenConns)
 
-	r := &SqlY{db: db}
-	switch opt.DriverName {
+	r := NewFromDB(db, opt.DriverName)
+	if opt.InsertChunkRows > 0 && (r.chunkRows == 0 || opt.InsertChunkRows < r.chunkRows) {
+		r.chunkRows = opt.InsertChunkRows
+	}
+	if opt.InsertChunkBytes != 0 {
+		r.chunkBytes = opt.InsertChunkBytes
+	}
+	if opt.StmtCacheSize > 0 {
+		r.EnableStmtCache(opt.StmtCacheSize)
+	}
+	return r, nil
+}
+
+// NewFromDB init SqlY with an existing database handle,
+// pool settings of db are left as they are
+func NewFromDB(db *sql.DB, driverName string) *SqlY {
+	r := &SqlY{db: db, server: &serverInfo{}, chunkBytes: defaultChunkBytes}
+	switch driverName {
 	case "mysql":
 		r.driver = driverMysql
-		argFmtFunc = mysqlArgFormat
+		r.argFmt = mysqlArgFormat
 	case "postgres":
 		r.driver = driverPostgresql
-		argFmtFunc = pgArgFormat
+		r.argFmt = pgArgFormat
+	case "sqlite3", "sqlite":
+		r.driver = driverSqlite
+		r.argFmt = sqliteArgFormat
+	case "sqlserver", "mssql":
+		r.driver = driverMssql
+		r.argFmt = mssqlArgFormat
+		r.chunkRows = mssqlMaxRows
+	case "clickhouse":
+		r.driver = driverClickhouse
+		r.argFmt = clickhouseArgFormat
 	default:
 		r.driver = driverOthers
-		argFmtFunc = mysqlArgFormat
+		r.argFmt = mysqlArgFormat
 	}
-	return r, nil
+	return r
+}
+
+// DB returns the underlying database handle
+func (s *SqlY) DB() *sql.DB {
+	return s.db
+}
+
+// DriverName returns the dialect of database, mysql, postgres, sqlite3, sqlserver, clickhouse,
+// or empty string for other drivers
+func (s *SqlY) DriverName() string {
+	switch s.driver {
+	case driverMysql:
+		return "mysql"
+	case driverPostgresql:
+		return "postgres"
+	case driverSqlite:
+		return "sqlite3"
+	case driverMssql:
+		return "sqlserver"
+	case driverClickhouse:
+		return "clickhouse"
+	}
+	return ""
+}
+
+// Stats returns database statistics of the connection pool
+func (s *SqlY) Stats() sql.DBStats {
+	return s.db.Stats()
 }
 
 // exec one sql statement with context
@@ -84,6 +179,34 @@ func (s *SqlY) execOneDb(ctx context.Context, query string) (*Affected, error) {
 	return aff, nil
 }
 
+// query with args, by cached prepared statement if it's enabled
+func (s *SqlY) queryArgs(ctx context.Context, query string, args []interface{}) (*sql.Rows, error) {
+	if s.stmts != nil && bindAble(s.driver, args) {
+		return s.stmts.query(ctx, rebind(s.driver, query), stmtArgs(s.driver, s.argFmt, args))
+	}
+	q, err := statementFormat(query, s.argFmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.QueryContext(ctx, q)
+}
+
+// exec with args, by cached prepared statement if it's enabled
+func (s *SqlY) execArgs(ctx context.Context, query string, args []interface{}) (*Affected, error) {
+	if s.stmts != nil && bindAble(s.driver, args) {
+		res, err := s.stmts.exec(ctx, rebind(s.driver, query), stmtArgs(s.driver, s.argFmt, args))
+		if err != nil {
+			return nil, err
+		}
+		return &Affected{result: res, driver: s.driver}, nil
+	}
+	q, err := statementFormat(query, s.argFmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	return s.execOneDb(ctx, q)
+}
+
 // exec sql statements
 func execManyDb(ctx context.Context, db *sql.DB, queries []string) error {
 	// start transaction
@@ -104,6 +227,119 @@ func execManyDb(ctx context.Context, db *sql.DB, queries []string) error {
 	return tx.Commit()
 }
 
+// insert many rows, and get all the generated ids in order of rows.
+// RETURNING (OUTPUT for sql server) clause is used for postgresql, sqlite and sql server.
+// for mysql the ids are derived from LastInsertId, rows affected and auto_increment_increment,
+// it's only reliable when innodb_autoinc_lock_mode is 0(traditional) or 1(consecutive), which
+// guarantee consecutive ids for multi rows insert, ErrIdsNotConsecutive is returned if it's 2(interleaved).
+// q should be a single connection (*sql.Conn or *sql.Tx) for mysql, as auto_increment_increment is a session variable,
+// rows of sql server are inserted in chunks of mssqlMaxRows, q should be *sql.Tx if there are more rows
+func insertManyReturningIDs(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, idField, query string,
+	args [][]interface{}) ([]int64, error) {
+	if len(args) == 0 {
+		return []int64{}, nil
+	}
+	switch driver {
+	case driverPostgresql, driverSqlite, driverMssql:
+		maxRows := 0
+		if driver == driverMssql {
+			maxRows = mssqlMaxRows
+		}
+		qs, err := multiRowsChunkFmt(driver, query, argFmt, args, maxRows, 0)
+		if err != nil {
+			return nil, err
+		}
+		var ids []int64
+		for _, mq := range qs {
+			rows, err := q.QueryContext(ctx, returningFmt(driver, mq, idField))
+			if err != nil {
+				return nil, err
+			}
+			var chunk []int64
+			if err := checkAllV2(rows, &chunk); err != nil {
+				return nil, err
+			}
+			ids = append(ids, chunk...)
+		}
+		return ids, nil
+	case driverMysql:
+		mq, err := multiRowsFmt(driver, query, argFmt, args)
+		if err != nil {
+			return nil, err
+		}
+		var lockMode, increment int64
+		err = q.QueryRowContext(ctx, "SELECT @@innodb_autoinc_lock_mode, @@auto_increment_increment").
+			Scan(&lockMode, &increment)
+		if err != nil {
+			return nil, err
+		}
+		if lockMode == 2 {
+			return nil, ErrIdsNotConsecutive
+		}
+		res, err := q.ExecContext(ctx, mq)
+		if err != nil {
+			return nil, err
+		}
+		first, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		// ON DUPLICATE KEY UPDATE and INSERT IGNORE make rows affected differ from rows count
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if n != int64(len(args)) {
+			return nil, ErrIdsNotConsecutive
+		}
+		ids := make([]int64, len(args))
+		for i := range ids {
+			ids[i] = first + int64(i)*increment
+		}
+		return ids, nil
+	default:
+		return nil, ErrNotSupportForThisDriver
+	}
+}
+
+// exec chunks of insert statements in one transaction
+func execChunksDb(ctx context.Context, db txBeginner, driver dbDriver, queries []string) (*Affected, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	aff, err := execChunksTx(ctx, tx, driver, queries)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return aff, nil
+}
+
+// insert rows in batch with prepared statement, for clickhouse
+func execBatchDb(ctx context.Context, db txBeginner, driver dbDriver, query string, args [][]interface{}) (*Affected, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	aff, err := execBatchTx(ctx, tx, driver, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return aff, nil
+}
+
 // Ping ping test
 func (s *SqlY) Ping() error {
 	return s.db.Ping()
@@ -111,99 +347,50 @@ func (s *SqlY) Ping() error {
 
 // Close close connection
 func (s *SqlY) Close() error {
+	if s.stmts != nil {
+		s.stmts.close()
+	}
 	return s.db.Close()
 }
 
 // Query query the database working with results
 func (s *SqlY) Query(dest interface{}, query string, args ...interface{}) error {
-	// query db
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		if errors.Is(err, ErrEmptyArrayInStatement) {
-			return nil
-		}
-		return err
-	}
-	rows, err := s.db.Query(q)
-	if err != nil {
-		return err
-	}
-	return checkAllV2(rows, dest)
+	return s.QueryCtx(context.Background(), dest, query, args...)
 }
 
 // Get query the database working with one result
 func (s *SqlY) Get(dest interface{}, query string, args ...interface{}) error {
-	// query db
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		if errors.Is(err, ErrEmptyArrayInStatement) {
-			return nil
-		}
-		return err
-	}
-	rows, err := s.db.Query(q)
-	if err != nil {
-		return err
-	}
-	return checkOneV2(rows, dest)
+	return s.GetCtx(context.Background(), dest, query, args...)
 }
 
 // Insert insert into the database
 func (s *SqlY) Insert(query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return s.execOneDb(context.Background(), q)
+	return s.InsertCtx(context.Background(), query, args...)
 }
 
 // InsertMany insert many values to database
 func (s *SqlY) InsertMany(query string, args [][]interface{}) (*Affected, error) {
-	q, err := multiRowsFmt(query, argFmtFunc, args)
-	if err != nil {
-		return nil, err
-	}
-	return s.execOneDb(context.Background(), q)
+	return s.InsertManyCtx(context.Background(), query, args)
 }
 
 // Update update value to database
 func (s *SqlY) Update(query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return s.execOneDb(context.Background(), q)
+	return s.UpdateCtx(context.Background(), query, args...)
 }
 
 // UpdateMany update many
 func (s *SqlY) UpdateMany(query string, args [][]interface{}) (*Affected, error) {
-	var q string
-	for _, arg := range args {
-		t, err := statementFormat(query, argFmtFunc, arg...)
-		if err != nil {
-			return nil, err
-		}
-		q += t + ";"
-	}
-	return s.execOneDb(context.Background(), q)
+	return s.UpdateManyCtx(context.Background(), query, args)
 }
 
 // Delete delete item from database
 func (s *SqlY) Delete(query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return s.execOneDb(context.Background(), q)
+	return s.DeleteCtx(context.Background(), query, args...)
 }
 
 // Exec general sql statement execute
 func (s *SqlY) Exec(query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return s.execOneDb(context.Background(), q)
+	return s.ExecCtx(context.Background(), query, args...)
 }
 
 // ExecMany execute multi sql statement
@@ -213,94 +400,85 @@ func (s *SqlY) ExecMany(queries []string) error {
 
 // QueryCtx query the database working with results
 func (s *SqlY) QueryCtx(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	// query db
-	q, err := statementFormat(query, argFmtFunc, args...)
+	rows, err := s.queryArgs(ctx, query, args)
 	if err != nil {
 		if errors.Is(err, ErrEmptyArrayInStatement) {
 			return nil
 		}
 		return err
 	}
-	rows, err := s.db.QueryContext(ctx, q)
-	if err != nil {
-		return err
-	}
 	return checkAllV2(rows, dest)
 }
 
 // GetCtx query the database working with one result
 func (s *SqlY) GetCtx(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	// query db
-	q, err := statementFormat(query, argFmtFunc, args...)
+	rows, err := s.queryArgs(ctx, query, args)
 	if err != nil {
 		if errors.Is(err, ErrEmptyArrayInStatement) {
 			return nil
 		}
 		return err
 	}
-	rows, err := s.db.QueryContext(ctx, q)
-	if err != nil {
-		return err
-	}
 	return checkOneV2(rows, dest)
 }
 
 // InsertCtx insert with context
 func (s *SqlY) InsertCtx(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return s.execOneDb(ctx, q)
+	return s.execArgs(ctx, query, args)
 }
 
 // InsertManyCtx insert many with context
+// the rows are split into chunks of statements by InsertChunkRows and InsertChunkBytes of Option,
+// chunks are executed in one transaction, and rows affected are summed, LastInsertId is the one of first chunk.
+// for clickhouse, rows are sent in one batch, and rows affected is not supported
 func (s *SqlY) InsertManyCtx(ctx context.Context, query string, args [][]interface{}) (*Affected, error) {
-	q, err := multiRowsFmt(query, argFmtFunc, args)
+	if s.driver == driverClickhouse {
+		return execBatchDb(ctx, s.db, s.driver, query, args)
+	}
+	qs, err := multiRowsChunkFmt(s.driver, query, s.argFmt, args, s.chunkRows, s.chunkBytes)
 	if err != nil {
 		return nil, err
 	}
-	return s.execOneDb(ctx, q)
+	if len(qs) == 1 {
+		return s.execOneDb(ctx, qs[0])
+	}
+	return execChunksDb(ctx, s.db, s.driver, qs)
 }
 
 // UpdateCtx update with context
 func (s *SqlY) UpdateCtx(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return s.execOneDb(ctx, q)
+	return s.execArgs(ctx, query, args)
 }
 
 // UpdateManyCtx update many
+// the query is prepared once and executed with each row of args in one transaction,
+// GetRowsAffected of the result is the total, and GetRowsAffectedList is the one of each row
 func (s *SqlY) UpdateManyCtx(ctx context.Context, query string, args [][]interface{}) (*Affected, error) {
-	var q string
-	for _, arg := range args {
-		t, err := statementFormat(query, argFmtFunc, arg...)
-		if err != nil {
-			return nil, err
-		}
-		q += t + ";"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
 	}
-	return s.execOneDb(ctx, q)
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	aff, err := execEachTx(ctx, tx, s.driver, s.argFmt, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return aff, nil
 }
 
 // DeleteCtx delete with context
 func (s *SqlY) DeleteCtx(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return s.execOneDb(ctx, q)
+	return s.execArgs(ctx, query, args)
 }
 
 // ExecCtx general sql statement execute with context
 func (s *SqlY) ExecCtx(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return s.execOneDb(ctx, q)
+	return s.execArgs(ctx, query, args)
 }
 
 // ExecManyCtx execute multi sql statement with context
@@ -322,7 +500,8 @@ func (s *SqlY) Transaction(txFunc TxFunc) (interface{}, error) {
 		_ = tx.Rollback()
 	}()
 
-	trans := Trans{tx: tx, driver: s.driver}
+	trans := Trans{tx: tx, driver: s.driver, sqlY: s}
+	trans.initStmtCache()
 	// run callback
 	result, errR := txFunc(&trans)
 	if errR != nil {
@@ -340,7 +519,9 @@ func (s *SqlY) NewTrans() (*Trans, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Trans{tx: tx, driver: s.driver}, nil
+	trans := &Trans{tx: tx, driver: s.driver, sqlY: s}
+	trans.initStmtCache()
+	return trans, nil
 }
 
 // PgExec execute  statement for postgresql
@@ -350,15 +531,17 @@ func (s *SqlY) PgExec(idField, query string, args ...interface{}) (*Affected, er
 
 // PgExecCtx execute  statement for postgresql with context
 // use this function when you want the LastInsertId
+// it works with sqlite (3.35+) too, which supports RETURNING clause,
+// and sql server, which uses OUTPUT INSERTED.idField instead
 func (s *SqlY) PgExecCtx(ctx context.Context, idField, query string, args ...interface{}) (*Affected, error) {
-	if s.driver != driverPostgresql {
+	if s.driver != driverPostgresql && s.driver != driverSqlite && s.driver != driverMssql {
 		return nil, ErrNotSupportForThisDriver
 	}
-	q, err := statementFormat(query, argFmtFunc, args...)
+	q, err := statementFormat(query, s.argFmt, args...)
 	if err != nil {
 		return nil, err
 	}
-	q = fmt.Sprintf("%s RETURNING %s", q, idField)
+	q = returningFmt(s.driver, q, idField)
 	var id int64
 	err = s.db.QueryRowContext(ctx, q).Scan(&id)
 	if err != nil {
@@ -370,3 +553,213 @@ func (s *SqlY) PgExecCtx(ctx context.Context, idField, query string, args ...int
 		driver:       s.driver,
 	}, nil
 }
+
+// ExecReturning execute statement with RETURNING clause, and scan the returned rows into dest,
+// dest can be a pointer of slice, struct or map, `RETURNING *` is appended if the query has no RETURNING clause.
+// it works with postgresql, sqlite (3.35+) and mariadb (10.5+)
+func (s *SqlY) ExecReturning(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ok, err := s.server.supportReturning(ctx, s.db, s.driver)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotSupportForThisDriver
+	}
+	q, err := statementFormat(query, s.argFmt, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := s.db.QueryContext(ctx, returningAllFmt(s.driver, q))
+	if err != nil {
+		return err
+	}
+	return checkDest(rows, dest)
+}
+
+// InsertManyReturningIDs insert many rows, and return all the generated ids in order of rows,
+// idField is the auto increment field, see insertManyReturningIDs for how mysql ids are derived
+func (s *SqlY) InsertManyReturningIDs(ctx context.Context, idField, query string, args [][]interface{}) ([]int64, error) {
+	if s.driver == driverMssql && len(args) > mssqlMaxRows {
+		// chunks are inserted in one transaction
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = tx.Rollback()
+		}()
+		ids, err := insertManyReturningIDs(ctx, tx, s.driver, s.argFmt, idField, query, args)
+		if err != nil {
+			return nil, err
+		}
+		return ids, tx.Commit()
+	}
+	if s.driver != driverMysql {
+		return insertManyReturningIDs(ctx, s.db, s.driver, s.argFmt, idField, query, args)
+	}
+	// session variables and LastInsertId must come from the same connection
+	c, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+	return insertManyReturningIDs(ctx, c, s.driver, s.argFmt, idField, query, args)
+}
+
+// Upsert insert row, or update it on conflict of conflictCols, row is a struct, struct pointer
+// or map[string]interface{}, all columns except conflictCols are updated if updateCols is empty.
+// mysql uses ON DUPLICATE KEY UPDATE (conflictCols is only for choosing updated columns),
+// postgresql and sqlite use ON CONFLICT (conflictCols) DO UPDATE
+func (s *SqlY) Upsert(ctx context.Context, table string, conflictCols []string, row interface{},
+	updateCols ...string) (UpsertStatus, error) {
+	return upsertOne(ctx, s.db, s.driver, s.argFmt, table, conflictCols, row, updateCols)
+}
+
+// UpsertMany upsert rows in one statement, rows is a slice of struct, struct pointer or map[string]interface{},
+// the status of each row is reported by postgresql only
+func (s *SqlY) UpsertMany(ctx context.Context, table string, conflictCols []string, rows interface{},
+	updateCols ...string) ([]UpsertStatus, error) {
+	return upsertMany(ctx, s.db, s.driver, s.argFmt, table, conflictCols, rows, updateCols)
+}
+
+// InsertStruct insert row (struct pointer) into table, columns are taken from `sql` tags,
+// the zero auto increment primary key (`sql:"id,pk"`) is skipped, and set with the generated id
+func (s *SqlY) InsertStruct(ctx context.Context, table string, row interface{}) (*Affected, error) {
+	return insertStruct(ctx, s.db, s.driver, s.argFmt, table, row)
+}
+
+// UpdateStruct update all columns of row (struct pointer), keyed by primary keys
+func (s *SqlY) UpdateStruct(ctx context.Context, table string, row interface{}) (*Affected, error) {
+	return updateStruct(ctx, s.db, s.driver, s.argFmt, table, row)
+}
+
+// DeleteByPK delete row (struct pointer) keyed by primary keys
+func (s *SqlY) DeleteByPK(ctx context.Context, table string, row interface{}) (*Affected, error) {
+	return deleteByPK(ctx, s.db, s.driver, s.argFmt, table, row)
+}
+
+// UpdateChanged update the fields of row (struct pointer) which are modified since tr.Track,
+// keyed by primary keys, nothing is executed if no field is modified
+func (s *SqlY) UpdateChanged(ctx context.Context, tr *Tracker, table string, row interface{}) (*Affected, error) {
+	return updateChanged(ctx, s.db, s.driver, s.argFmt, tr, table, row)
+}
+
+// Paginate query rows of page to dest (slice pointer),
+// offset mode appends LIMIT/OFFSET to query, and counts total rows if opts.Count is set,
+// keyset mode seeks on opts.Key from opts.Cursor, and returns the cursor of next page
+func (s *SqlY) Paginate(ctx context.Context, dest interface{}, query string, opts PageOpts,
+	args ...interface{}) (*Page, error) {
+	return paginate(ctx, s.db, s.driver, s.argFmt, dest, query, opts, args...)
+}
+
+// EnableStmtCache cache at most size prepared statements keyed by sql text (LRU),
+// statements of Query, Get, Insert, Update, Delete and Exec are prepared and sent with bind args,
+// unless there are array arguments, which are expanded by formatting the statement.
+// transactions rebind the cached statements. size <= 0 disables the cache
+func (s *SqlY) EnableStmtCache(size int) {
+	if s.stmts != nil {
+		s.stmts.close()
+		s.stmts = nil
+	}
+	if size > 0 {
+		s.stmts = newStmtCache(size, s.db.PrepareContext)
+	}
+}
+
+// StmtCacheStats statistics of prepared statement cache
+func (s *SqlY) StmtCacheStats() StmtCacheStats {
+	if s.stmts == nil {
+		return StmtCacheStats{}
+	}
+	return s.stmts.statistics()
+}
+
+// CopyFrom bulk load rows into table with COPY FROM of postgresql in a transaction, returns the number of rows copied,
+// source is [][]interface{} in order of columns, slice of struct (struct pointer, map[string]interface{}) or RowSource
+func (s *SqlY) CopyFrom(ctx context.Context, table string, columns []string, source interface{}) (int64, error) {
+	if s.driver != driverPostgresql {
+		return 0, ErrNotSupportForThisDriver
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	n, err := copyFrom(ctx, tx, table, columns, source)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// LoadData bulk load rows into table with LOAD DATA LOCAL INFILE of mysql, reader handler is registered by importing mysqlreader,
+// source is [][]interface{} in order of columns, slice of struct (struct pointer, map[string]interface{}) or RowSource
+func (s *SqlY) LoadData(ctx context.Context, table string, columns []string, source interface{}) (*Affected, error) {
+	return loadData(ctx, s.db, s.driver, table, columns, source)
+}
+
+// Notify send notification to channel with postgresql NOTIFY, which is received by Listener of pqlisten
+func (s *SqlY) Notify(ctx context.Context, channel, payload string) error {
+	if s.driver != driverPostgresql {
+		return ErrNotSupportForThisDriver
+	}
+	_, err := s.ExecCtx(ctx, "SELECT pg_notify(?, ?)", channel, payload)
+	return err
+}
+
+// WithLock run fn holding the lock of key, it waits until the lock is acquired.
+// the lock is held by one pinned connection, postgresql uses pg_advisory_lock, mysql uses GET_LOCK,
+// sql server uses sp_getapplock, and it's released after fn returns
+func (s *SqlY) WithLock(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	_, err := withLock(ctx, s.db, s.driver, s.argFmt, key, true, fn)
+	return err
+}
+
+// TryLock run fn holding the lock of key if it's acquired immediately, false if the lock is held by others
+func (s *SqlY) TryLock(ctx context.Context, key string, fn func(ctx context.Context) error) (bool, error) {
+	return withLock(ctx, s.db, s.driver, s.argFmt, key, false, fn)
+}
+
+// ClaimBatch claim up to n rows of table matching cond in a transaction, as Trans.ClaimBatch,
+// the claimed rows are marked and committed before they are returned, which works as a job queue consumer
+func (s *SqlY) ClaimBatch(ctx context.Context, n int64, dest interface{}, table string, cond Cond,
+	mark interface{}) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := claimBatch(ctx, tx, s.driver, s.argFmt, n, dest, table, cond, mark); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Tables tables and views of current schema (database), ordered by name
+func (s *SqlY) Tables(ctx context.Context) ([]*Table, error) {
+	return listTables(ctx, s.QueryCtx, s.driver)
+}
+
+// Columns columns of table in order, table can be qualified by schema, such as public.account
+func (s *SqlY) Columns(ctx context.Context, table string) ([]*Column, error) {
+	return listColumns(ctx, s.QueryCtx, s.driver, table)
+}
+
+// Indexes indexes of table, ordered by name, the columns of each index are in order of index
+func (s *SqlY) Indexes(ctx context.Context, table string) ([]*Index, error) {
+	return listIndexes(ctx, s.QueryCtx, s.driver, table)
+}
+
+// ForeignKeys foreign keys of table, ordered by name
+func (s *SqlY) ForeignKeys(ctx context.Context, table string) ([]*ForeignKey, error) {
+	return listForeignKeys(ctx, s.QueryCtx, s.driver, table)
+}
@@ -3,11 +3,18 @@ package sqly
 import (
 	"context"
 	"crypto/sha1"
+	"database/sql"
+	"database/sql/driver"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -22,6 +29,74 @@ var opt = &Option{
 	ConnMaxLifeTime: 0,
 }
 
+var (
+	testOnce sync.Once
+	testOpt  *Option
+	testDir  string
+)
+
+// option of database for tests, mysql server of opt if it's reachable,
+// otherwise a sqlite database in temp directory with the account table, so tests run without mysql
+func testOption(t *testing.T) *Option {
+	testOnce.Do(func() {
+		db, err := New(&Option{Dsn: opt.Dsn, DriverName: opt.DriverName, ConnTimeout: time.Second})
+		if err == nil {
+			_ = db.Close()
+			testOpt = opt
+			return
+		}
+		if testDir, err = ioutil.TempDir("", "sqly"); err != nil {
+			return
+		}
+		sqliteOpt := &Option{Dsn: "file:" + filepath.Join(testDir, "test.db") + "?_busy_timeout=5000", DriverName: "sqlite3"}
+		if db, err = New(sqliteOpt); err != nil {
+			return
+		}
+		defer db.Close()
+		if err = db.ExecMany(sqliteAccountTable); err == nil {
+			testOpt = sqliteOpt
+		}
+	})
+	if testOpt == nil {
+		t.Fatal("no database for tests")
+	}
+	return testOpt
+}
+
+// statement to create the account table of db
+func accountTable(db *SqlY) string {
+	if db.driver == driverSqlite {
+		return strings.Join(sqliteAccountTable, "")
+	}
+	return "DROP TABLE IF EXISTS `account`;" +
+		"CREATE TABLE `account` (" +
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT," +
+		"`nickname` varchar(32) COLLATE utf8mb4_unicode_ci NOT NULL," +
+		"`avatar` varchar(200) COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT 'avatar url'," +
+		"`mobile` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'mobile number'," +
+		"`email` varchar(320) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT 'email'," +
+		"`password` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT 'password'," +
+		"`role` tinyint(4) DEFAULT '0' COMMENT 'role'," +
+		"`tags` varchar(320) COMMENT 'tags'," +
+		"`is_valid` tinyint(4) DEFAULT NULL COMMENT 'is_valid'," +
+		"`stature` float(5,2) DEFAULT NULL COMMENT 'stature'," +
+		"`create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+		"`add_time` datetime DEFAULT NULL, " +
+		"`birthday` date DEFAULT NULL, " +
+		"PRIMARY KEY (`id`)," +
+		"UNIQUE KEY `mobile_index` (`mobile`)," +
+		"KEY `email_index` (`email`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;"
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if testDir != "" {
+		_ = os.RemoveAll(testDir)
+	}
+	os.Exit(code)
+}
+
 // user model
 type Account struct {
 	ID         int64       `sql:"id" json:"id"`
@@ -40,16 +115,92 @@ type Account struct {
 }
 
 func TestNew(t *testing.T) {
-	_, err := New(opt)
+	_, err := New(testOption(t))
 	if err != nil {
 		t.Error(err)
 	}
 }
 
+func TestNew_PingRetry(t *testing.T) {
+	pings := 0
+	fakePinger.ping = func(ctx context.Context) error {
+		pings++
+		if pings <= 2 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	defer func() { fakePinger.ping = nil }()
+	db, err := New(&Option{Dsn: "fake", DriverName: "fake_ping", ConnRetries: 2, ConnRetryInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+	if pings != 3 {
+		t.Errorf("expect 3 pings, got %d", pings)
+	}
+
+	// the database is closed when all pings failed
+	pings = 0
+	fakePinger.opened, fakePinger.closed = 0, 0
+	_, err = New(&Option{Dsn: "fake", DriverName: "fake_ping", ConnRetries: 1})
+	if err == nil || err.Error() != "connection refused" {
+		t.Errorf("expect ping error, got %v", err)
+	}
+	if pings != 2 {
+		t.Errorf("expect 2 pings, got %d", pings)
+	}
+	if fakePinger.opened == 0 || fakePinger.opened != fakePinger.closed {
+		t.Errorf("opened %d connections, closed %d", fakePinger.opened, fakePinger.closed)
+	}
+}
+
+func TestNew_PingTimeout(t *testing.T) {
+	fakePinger.ping = func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	defer func() { fakePinger.ping = nil }()
+	start := time.Now()
+	_, err := New(&Option{Dsn: "fake", DriverName: "fake_ping", ConnTimeout: 10 * time.Millisecond, ConnRetries: 1})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expect deadline exceeded, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("ping takes %s", d)
+	}
+}
+
+func TestNewFromDB(t *testing.T) {
+	sqlDB, err := sql.Open("fake_ping", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(3)
+	for name, cmp := range map[string]string{"mysql": "mysql", "postgres": "postgres", "sqlite": "sqlite3",
+		"mssql": "sqlserver", "clickhouse": "clickhouse", "other": ""} {
+		if res := NewFromDB(sqlDB, name).DriverName(); res != cmp {
+			t.Errorf("driver name of %s: got %s", name, res)
+		}
+	}
+	db := NewFromDB(sqlDB, "postgres")
+	defer db.Close()
+	if db.DB() != sqlDB {
+		t.Error("unexpected database handle")
+	}
+	if err = db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	// pool settings of db are kept
+	if stats := db.Stats(); stats.MaxOpenConnections != 3 || stats.OpenConnections != 1 || stats.Idle != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
 func TestSqlY_Exec(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	//query := "CREATE DATABASE `test_db`;"
 	//_, err = db.Exec(query)
@@ -57,25 +208,7 @@ func TestSqlY_Exec(t *testing.T) {
 	//	t.Error(err)
 	//}
 
-	query := "DROP TABLE IF EXISTS `account`;" +
-		"CREATE TABLE `account` (" +
-		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT," +
-		"`nickname` varchar(32) COLLATE utf8mb4_unicode_ci NOT NULL," +
-		"`avatar` varchar(200) COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT 'avatar url'," +
-		"`mobile` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'mobile number'," +
-		"`email` varchar(320) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT 'email'," +
-		"`password` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT 'password'," +
-		"`role` tinyint(4) DEFAULT '0' COMMENT 'role'," +
-		"`tags` varchar(320) COMMENT 'tags'," +
-		"`is_valid` tinyint(4) DEFAULT NULL COMMENT 'is_valid'," +
-		"`stature` float(5,2) DEFAULT NULL COMMENT 'stature'," +
-		"`create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP," +
-		"`add_time` datetime DEFAULT NULL, " +
-		"`birthday` date DEFAULT NULL, " +
-		"PRIMARY KEY (`id`)," +
-		"UNIQUE KEY `mobile_index` (`mobile`)," +
-		"KEY `email_index` (`email`)" +
-		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;"
+	query := accountTable(db)
 	_, err = db.Exec(query)
 	if err != nil {
 		t.Error(err)
@@ -83,9 +216,9 @@ func TestSqlY_Exec(t *testing.T) {
 }
 
 func TestSqlY_Insert(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "INSERT INTO `account` (`nickname`, `mobile`, `email`, `role`, `tags`) " +
 		"VALUES (?, ?, ?, ?, ?);"
@@ -98,9 +231,9 @@ func TestSqlY_Insert(t *testing.T) {
 }
 
 func TestSqlY_Update(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "UPDATE `account` SET `nickname`=? WHERE `mobile`=?;"
 	aff, err := db.Update(query, "lucy", "18812311231")
@@ -111,9 +244,9 @@ func TestSqlY_Update(t *testing.T) {
 }
 
 func TestSqlY_InsertCtx(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "INSERT INTO `account` (`nickname`, `mobile`, `email`, `role`) " +
 		"VALUES (?, ?, ?, ?);"
@@ -126,9 +259,9 @@ func TestSqlY_InsertCtx(t *testing.T) {
 }
 
 func TestSqlY_InsertMany(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "INSERT INTO `account` (`nickname`, `mobile`, `email`, `role`, `tags`) " +
 		"VALUES (?, ?, ?, ?, ?);"
@@ -141,13 +274,13 @@ func TestSqlY_InsertMany(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(aff)
+	fmt.Println(aff)
 }
 
 func TestSqlY_QueryOne(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
 	acc := new(Account)
@@ -160,9 +293,9 @@ func TestSqlY_QueryOne(t *testing.T) {
 }
 
 func TestSqlY_Query(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	var accs []*Account
 	query := "SELECT `id`, `nickname`, `avatar`, `email`, `mobile`, `password`, `role`, `create_time`, `tags` FROM `account`;"
@@ -176,9 +309,9 @@ func TestSqlY_Query(t *testing.T) {
 }
 
 func TestSqlY_Query_All(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	var accs []*Account
 	query := "SELECT * FROM `account` limit 1;"
@@ -192,9 +325,9 @@ func TestSqlY_Query_All(t *testing.T) {
 }
 
 func TestSqlY_Get_All(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	acc := &Account{}
 	query := "SELECT * FROM `account` WHERE `id`=2"
@@ -208,9 +341,9 @@ func TestSqlY_Get_All(t *testing.T) {
 }
 
 func TestSqlY_Delete(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "DELETE FROM `account` WHERE `mobile`=?;"
 	aff, err := db.Delete(query, "18812311231")
@@ -221,9 +354,9 @@ func TestSqlY_Delete(t *testing.T) {
 }
 
 func TestSqlY_QueryCtx(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "SELECT `id`, `nickname`, `avatar`, `email`, `mobile`, `password`, `role`, `tags` " +
 		"FROM `account` WHERE `avatar` IS ?;"
@@ -238,9 +371,9 @@ func TestSqlY_QueryCtx(t *testing.T) {
 }
 
 func TestSqlY_GetCtx(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "SELECT `id`, `nickname`, `avatar`, `email`, `mobile`, `password`, `role`, `create_time`, `tags` " +
 		"FROM `account` WHERE `mobile`=?;"
@@ -260,9 +393,9 @@ func TestSqlY_GetCtx(t *testing.T) {
 }
 
 func TestSqlY_GetCtx_Empty(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "SELECT `id`, `nickname`, `avatar`, `email`, `mobile`, `password`, `role`, `create_time` , `tags`" +
 		"FROM `account` WHERE `mobile`=?;"
@@ -275,9 +408,9 @@ func TestSqlY_GetCtx_Empty(t *testing.T) {
 }
 
 func TestSqlY_GetCtx_Multi(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "SELECT `id`, `nickname`, `avatar`, `email`, `mobile`, `password`, `role`, `create_time`, `tags` " +
 		"FROM `account`;"
@@ -290,9 +423,9 @@ func TestSqlY_GetCtx_Multi(t *testing.T) {
 }
 
 func TestSqlY_ExecManyCtx(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	ctx := context.TODO()
 	var queries []string
@@ -307,10 +440,9 @@ func TestSqlY_ExecManyCtx(t *testing.T) {
 }
 
 func TestSqlY_NewTrans(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
 	ts, err := db.NewTrans()
 	if err != nil {
@@ -349,10 +481,9 @@ func TestSqlY_NewTrans(t *testing.T) {
 }
 
 func TestBoolean_Scan(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
 
 	type Acc struct {
@@ -389,10 +520,9 @@ func TestBoolean_Scan(t *testing.T) {
 }
 
 func TestStruct_Nest(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
 	type Contact struct {
 		Email  string `sql:"email" json:"email"`
@@ -425,10 +555,9 @@ func TestStruct_Nest(t *testing.T) {
 }
 
 func TestStructNest2(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
 	type Contact struct {
 		Email  string `sql:"email" json:"email"`
@@ -457,10 +586,9 @@ func TestStructNest2(t *testing.T) {
 }
 
 func TestStructNest3(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
 	type Contact struct {
 		Email  string `sql:"email" json:"email"`
@@ -490,10 +618,9 @@ func TestStructNest3(t *testing.T) {
 }
 
 func TestSqlY_UpdateMany(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
 
 	type accIDs struct {
@@ -523,9 +650,9 @@ func TestSqlY_UpdateMany(t *testing.T) {
 }
 
 func TestSqlY_NullTime(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "INSERT INTO `account` (`nickname`, `mobile`, `email`, `add_time`, `birthday`) " +
 		"VALUES (?, ?, ?, ?, ?);"
@@ -543,9 +670,9 @@ func TestSqlY_NullTime(t *testing.T) {
 }
 
 func TestSqlY_BaseType(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
 	query := "SELECT `id` FROM `account` ORDER BY `id`;"
@@ -559,9 +686,9 @@ func TestSqlY_BaseType(t *testing.T) {
 }
 
 func TestSqlY_BaseType2(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
 	query := "SELECT `add_time` FROM `account` ORDER BY `id`;"
@@ -576,9 +703,9 @@ func TestSqlY_BaseType2(t *testing.T) {
 }
 
 func TestSqly_BaseType3(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
 	query := "SELECT `nickname` FROM `account` ORDER BY `id`;"
@@ -591,9 +718,9 @@ func TestSqly_BaseType3(t *testing.T) {
 }
 
 func TestSqlY_OneBase(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "SELECT COUNT(*) FROM `account`;"
 	var num int
@@ -605,9 +732,9 @@ func TestSqlY_OneBase(t *testing.T) {
 }
 
 func TestSqlY_OneBase2(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "SELECT `create_time` FROM `account` limit 1;"
 	create := &NullTime{}
@@ -619,9 +746,9 @@ func TestSqlY_OneBase2(t *testing.T) {
 }
 
 func TestSqly_OneBase3(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "SELECT `nickname` FROM `account` limit 1;"
 	var nickname string
@@ -633,9 +760,9 @@ func TestSqly_OneBase3(t *testing.T) {
 }
 
 func TestSqlY_QueryOneMap(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
 	acc := make(map[string]interface{})
@@ -649,9 +776,9 @@ func TestSqlY_QueryOneMap(t *testing.T) {
 }
 
 func TestSqlY_QueryMap(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	var accs []map[string]interface{}
 	query := "SELECT * FROM `account`;"
@@ -665,9 +792,9 @@ func TestSqlY_QueryMap(t *testing.T) {
 }
 
 func TestSqly_EmptyArray(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	var accs []map[string]interface{}
 	query := "SELECT * FROM `account` WHERE `id` IN ?;"
@@ -681,9 +808,9 @@ func TestSqly_EmptyArray(t *testing.T) {
 }
 
 func TestSqly_EmptyArray2(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	query := "UPDATE `account` SET `nickname`=`nickname`+'t' WHERE `id` IN ?;"
 	var ids []int64
@@ -691,7 +818,7 @@ func TestSqly_EmptyArray2(t *testing.T) {
 	if !errors.Is(err, ErrEmptyArrayInStatement) {
 		t.Error(err)
 	}
-	fmt.Sprintln(aff)
+	fmt.Println(aff)
 }
 
 func TestSqlY_Json(t *testing.T) {
@@ -716,9 +843,9 @@ func TestSqlY_Json(t *testing.T) {
 }
 
 func TestSqlY_Query2(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	// user model
 	type Acc struct {
@@ -738,9 +865,9 @@ func TestSqlY_Query2(t *testing.T) {
 }
 
 func TestSqlY_Nest4(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	type Contact struct {
 		Email  string                 `sql:"email" json:"email"`
@@ -765,3 +892,42 @@ func TestSqlY_Nest4(t *testing.T) {
 	accStr, _ := json.Marshal(accs)
 	fmt.Printf("rows %s", accStr)
 }
+
+// mysql server with the given innodb_autoinc_lock_mode and auto_increment_increment
+func mysqlAutoIncHandler(lockMode, increment, lastId, aff int64) fakeHandler {
+	return func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if strings.HasPrefix(query, "SELECT @@innodb_autoinc_lock_mode") {
+			return &fakeResult{
+				columns: []fakeColumn{{name: "lock_mode"}, {name: "increment"}},
+				rows:    [][]driver.Value{{lockMode, increment}},
+			}, nil
+		}
+		return &fakeResult{lastId: lastId, aff: aff}, nil
+	}
+}
+
+func TestMysql_InsertManyReturningIDs(t *testing.T) {
+	query := "INSERT INTO `account` (`nickname`, `mobile`) VALUES (?, ?)"
+	args := [][]interface{}{{"nick1", "18812311231"}, {"nick2", "18812311232"}, {"nick3", "18812311233"}}
+
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, mysqlAutoIncHandler(1, 2, 11, 3))
+	ids, err := db.InsertManyReturningIDs(context.TODO(), "id", query, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || ids[0] != 11 || ids[1] != 13 || ids[2] != 15 {
+		t.Errorf("unexpected ids %v", ids)
+	}
+
+	db = newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, mysqlAutoIncHandler(2, 1, 11, 3))
+	_, err = db.InsertManyReturningIDs(context.TODO(), "id", query, args)
+	if !errors.Is(err, ErrIdsNotConsecutive) {
+		t.Errorf("interleaved lock mode, got %v", err)
+	}
+
+	db = newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, mysqlAutoIncHandler(1, 1, 11, 4))
+	_, err = db.InsertManyReturningIDs(context.TODO(), "id", query, args)
+	if !errors.Is(err, ErrIdsNotConsecutive) {
+		t.Errorf("rows affected mismatch, got %v", err)
+	}
+}
@@ -3,8 +3,8 @@ package sqly
 import (
 	"bytes"
 	"database/sql/driver"
+	"encoding/hex"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -78,6 +78,11 @@ func mysqlArgFormat(delim string, item interface{}) (string, error) {
 			return strconv.FormatInt(v.Int64, 10), nil
 		}
 		return "NULL", nil
+	case NullUint64:
+		if v.Valid {
+			return strconv.FormatUint(v.Uint64, 10), nil
+		}
+		return "NULL", nil
 	case NullInt32:
 		if v.Valid || v.Int32 != 0 {
 			return strconv.FormatInt(int64(v.Int32), 10), nil
@@ -502,6 +507,447 @@ func pgArgFormat(delim string, item interface{}) (string, error) {
 	}
 }
 
+func arrToStrSqlite(v driver.Value, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "NULL", nil
+	}
+	return SqliteString(v.(string)), nil
+}
+
+// sqliteTimeFormat time layout that sqlite drivers can parse back to time.Time
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// sqlite 参数 format
+// sqlite has no backslash escapes in string literal, bool is stored as 0/1,
+// and time is stored as text, those differ from mysql, the others are the same
+func sqliteArgFormat(delim string, item interface{}) (string, error) {
+	if item == nil {
+		return "NULL", nil
+	}
+	ref := reflect.Indirect(reflect.ValueOf(item)).Interface()
+	switch v := ref.(type) {
+	case string:
+		return SqliteString(v), nil
+	case time.Time:
+		return SqliteString(v.Format(sqliteTimeFormat)), nil
+	case NullString:
+		if v.Valid || v.String != "" {
+			return SqliteString(v.String), nil
+		}
+		return "NULL", nil
+	case NullTime:
+		if !v.Valid && v.Time.IsZero() {
+			return "NULL", nil
+		}
+		return SqliteString(v.Time.Format(sqliteTimeFormat)), nil
+	case []string:
+		if len(v) == 0 {
+			return "", ErrEmptyArrayInStatement
+		}
+		var buffer bytes.Buffer
+		buffer.WriteString("(")
+		for i := 0; i < len(v); i++ {
+			buffer.WriteString(SqliteString(v[i]))
+			if i != len(v)-1 {
+				buffer.WriteString(delim)
+			}
+		}
+		buffer.WriteString(")")
+		return buffer.String(), nil
+	case []time.Time:
+		if len(v) == 0 {
+			return "", ErrEmptyArrayInStatement
+		}
+		var buffer bytes.Buffer
+		buffer.WriteString("(")
+		for i := 0; i < len(v); i++ {
+			buffer.WriteString(SqliteString(v[i].Format(sqliteTimeFormat)))
+			if i != len(v)-1 {
+				buffer.WriteString(delim)
+			}
+		}
+		buffer.WriteString(")")
+		return buffer.String(), nil
+	case []byte:
+		// blob literal
+		return "X'" + hex.EncodeToString(v) + "'", nil
+	case BoolArray:
+		b, err := v.Value()
+		return arrToStrSqlite(b, err)
+	case ByteaArray:
+		b, err := v.Value()
+		return arrToStrSqlite(b, err)
+	case Float64Array:
+		b, err := v.Value()
+		return arrToStrSqlite(b, err)
+	case Float32Array:
+		b, err := v.Value()
+		return arrToStrSqlite(b, err)
+	case GenericArray:
+		b, err := v.Value()
+		return arrToStrSqlite(b, err)
+	case Int64Array:
+		b, err := v.Value()
+		return arrToStrSqlite(b, err)
+	case StringArray:
+		b, err := v.Value()
+		return arrToStrSqlite(b, err)
+	default:
+		return mysqlArgFormat(delim, v)
+	}
+}
+
+func arrToStrMssql(v driver.Value, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "NULL", nil
+	}
+	return MssqlString(v.(string)), nil
+}
+
+// mssqlTimeFormat ISO 8601 layout that datetime2 accepts
+const mssqlTimeFormat = "2006-01-02T15:04:05.9999999"
+
+// sql server 参数 format
+// sql server uses N'...' for unicode string literal, in which quote is doubled,
+// bool is stored as bit 0/1, the others are the same as mysql
+func mssqlArgFormat(delim string, item interface{}) (string, error) {
+	if item == nil {
+		return "NULL", nil
+	}
+	ref := reflect.Indirect(reflect.ValueOf(item)).Interface()
+	switch v := ref.(type) {
+	case string:
+		return MssqlString(v), nil
+	case time.Time:
+		return SingleQuote(v.Format(mssqlTimeFormat)), nil
+	case NullString:
+		if v.Valid || v.String != "" {
+			return MssqlString(v.String), nil
+		}
+		return "NULL", nil
+	case NullTime:
+		if !v.Valid && v.Time.IsZero() {
+			return "NULL", nil
+		}
+		return SingleQuote(v.Time.Format(mssqlTimeFormat)), nil
+	case []string:
+		if len(v) == 0 {
+			return "", ErrEmptyArrayInStatement
+		}
+		var buffer bytes.Buffer
+		buffer.WriteString("(")
+		for i := 0; i < len(v); i++ {
+			buffer.WriteString(MssqlString(v[i]))
+			if i != len(v)-1 {
+				buffer.WriteString(delim)
+			}
+		}
+		buffer.WriteString(")")
+		return buffer.String(), nil
+	case []time.Time:
+		if len(v) == 0 {
+			return "", ErrEmptyArrayInStatement
+		}
+		var buffer bytes.Buffer
+		buffer.WriteString("(")
+		for i := 0; i < len(v); i++ {
+			buffer.WriteString(SingleQuote(v[i].Format(mssqlTimeFormat)))
+			if i != len(v)-1 {
+				buffer.WriteString(delim)
+			}
+		}
+		buffer.WriteString(")")
+		return buffer.String(), nil
+	case []byte:
+		// binary literal
+		return "0x" + hex.EncodeToString(v), nil
+	case BoolArray:
+		b, err := v.Value()
+		return arrToStrMssql(b, err)
+	case ByteaArray:
+		b, err := v.Value()
+		return arrToStrMssql(b, err)
+	case Float64Array:
+		b, err := v.Value()
+		return arrToStrMssql(b, err)
+	case Float32Array:
+		b, err := v.Value()
+		return arrToStrMssql(b, err)
+	case GenericArray:
+		b, err := v.Value()
+		return arrToStrMssql(b, err)
+	case Int64Array:
+		b, err := v.Value()
+		return arrToStrMssql(b, err)
+	case StringArray:
+		b, err := v.Value()
+		return arrToStrMssql(b, err)
+	default:
+		return mysqlArgFormat(delim, v)
+	}
+}
+
+// clickhouse array literal, such as [1,2,3] and ['a','b']
+func arrToStrClickhouse(arr interface{}) (string, error) {
+	v := reflect.ValueOf(arr)
+	var buffer bytes.Buffer
+	buffer.WriteString("[")
+	for i := 0; i < v.Len(); i++ {
+		t, err := clickhouseArgFormat(",", v.Index(i).Interface())
+		if err != nil {
+			return "", err
+		}
+		buffer.WriteString(t)
+		if i != v.Len()-1 {
+			buffer.WriteString(",")
+		}
+	}
+	buffer.WriteString("]")
+	return buffer.String(), nil
+}
+
+// clickhouseTime DateTime64 literal with nanosecond precision
+func clickhouseTime(t time.Time) string {
+	return "toDateTime64(" + SingleQuote(t.UTC().Format("2006-01-02 15:04:05.999999999")) + ", 9, 'UTC')"
+}
+
+// clickhouse 参数 format
+// typed arrays are formatted as Array literal [...], Tuple as tuple literal (...),
+// time as DateTime64 in UTC, string is escaped with backslash as mysql
+func clickhouseArgFormat(delim string, item interface{}) (string, error) {
+	if item == nil {
+		return "NULL", nil
+	}
+	ref := reflect.Indirect(reflect.ValueOf(item)).Interface()
+	switch v := ref.(type) {
+	case time.Time:
+		return clickhouseTime(v), nil
+	case NullTime:
+		if !v.Valid && v.Time.IsZero() {
+			return "NULL", nil
+		}
+		return clickhouseTime(v.Time), nil
+	case []time.Time:
+		if len(v) == 0 {
+			return "", ErrEmptyArrayInStatement
+		}
+		var buffer bytes.Buffer
+		buffer.WriteString("(")
+		for i := 0; i < len(v); i++ {
+			buffer.WriteString(clickhouseTime(v[i]))
+			if i != len(v)-1 {
+				buffer.WriteString(delim)
+			}
+		}
+		buffer.WriteString(")")
+		return buffer.String(), nil
+	case Tuple:
+		var buffer bytes.Buffer
+		buffer.WriteString("(")
+		for i := 0; i < len(v); i++ {
+			t, err := clickhouseArgFormat(delim, v[i])
+			if err != nil {
+				return "", err
+			}
+			buffer.WriteString(t)
+			if i != len(v)-1 {
+				buffer.WriteString(delim)
+			}
+		}
+		buffer.WriteString(")")
+		return buffer.String(), nil
+	case BoolArray:
+		return arrToStrClickhouse([]bool(v))
+	case ByteaArray:
+		return arrToStrClickhouse([][]byte(v))
+	case Float64Array:
+		return arrToStrClickhouse([]float64(v))
+	case Float32Array:
+		return arrToStrClickhouse([]float32(v))
+	case Int64Array:
+		return arrToStrClickhouse([]int64(v))
+	case Int32Array:
+		return arrToStrClickhouse([]int32(v))
+	case StringArray:
+		return arrToStrClickhouse([]string(v))
+	case GenericArray:
+		if reflect.ValueOf(v.A).Kind() != reflect.Slice {
+			return "", ErrArgType
+		}
+		return arrToStrClickhouse(v.A)
+	default:
+		return mysqlArgFormat(delim, v)
+	}
+}
+
+// bindArg convert the argument to the value that driver accepts,
+// for the types of sqly which has no driver.Valuer implementation
+func bindArg(driver dbDriver, item interface{}) interface{} {
+	if item == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(item)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		item = rv.Elem().Interface()
+	}
+	switch v := item.(type) {
+	case NullInt64:
+		if v.Valid {
+			return v.Int64
+		}
+		return nil
+	case NullUint64:
+		if v.Valid {
+			return v.Uint64
+		}
+		return nil
+	case NullInt32:
+		if v.Valid {
+			return v.Int32
+		}
+		return nil
+	case NullFloat64:
+		if v.Valid {
+			return v.Float64
+		}
+		return nil
+	case NullString:
+		if v.Valid {
+			return v.String
+		}
+		return nil
+	case NullBool:
+		if v.Valid {
+			return v.Bool
+		}
+		return nil
+	case NullTime:
+		if v.Valid {
+			return v.Time
+		}
+		return nil
+	case Boolean:
+		return bool(v)
+	}
+	if driver != driverClickhouse {
+		return item
+	}
+	// clickhouse driver accepts go slices for Array columns
+	switch v := item.(type) {
+	case BoolArray:
+		return []bool(v)
+	case ByteaArray:
+		return [][]byte(v)
+	case Float64Array:
+		return []float64(v)
+	case Float32Array:
+		return []float32(v)
+	case Int64Array:
+		return []int64(v)
+	case Int32Array:
+		return []int32(v)
+	case StringArray:
+		return []string(v)
+	case GenericArray:
+		return v.A
+	case Tuple:
+		return []interface{}(v)
+	}
+	return item
+}
+
+// bindArgs convert arguments to the values that driver accepts
+func bindArgs(driver dbDriver, args []interface{}) []interface{} {
+	res := make([]interface{}, len(args))
+	for i, arg := range args {
+		res[i] = bindArg(driver, arg)
+	}
+	return res
+}
+
+// layout of time literal of driver, the same as argFormat of driver writes
+func timeLayout(driver dbDriver) string {
+	switch driver {
+	case driverSqlite:
+		return sqliteTimeFormat
+	case driverMssql:
+		return mssqlTimeFormat
+	}
+	return "2006-01-02 15:04:05.000000000"
+}
+
+// stmtArg convert the argument to the value bound to prepared statement, which is stored as argFmt formats it,
+// null types are NULL only if argFmt formats them as NULL, and times are bound as the text of time literal,
+// so the stored value doesn't depend on the location setting of driver
+func stmtArg(driver dbDriver, argFmt argFormat, item interface{}) interface{} {
+	if rv := reflect.ValueOf(item); item == nil || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	item = reflect.Indirect(reflect.ValueOf(item)).Interface()
+	isNull := func() bool {
+		lit, err := argFmt(",", item)
+		return err == nil && lit == "NULL"
+	}
+	switch v := item.(type) {
+	case time.Time:
+		return v.Format(timeLayout(driver))
+	case NullTime:
+		if isNull() {
+			return nil
+		}
+		return v.Time.Format(timeLayout(driver))
+	case NullInt64:
+		if isNull() {
+			return nil
+		}
+		return v.Int64
+	case NullUint64:
+		if isNull() {
+			return nil
+		}
+		return v.Uint64
+	case NullInt32:
+		if isNull() {
+			return nil
+		}
+		return v.Int32
+	case NullFloat64:
+		if isNull() {
+			return nil
+		}
+		return v.Float64
+	case NullString:
+		if isNull() {
+			return nil
+		}
+		return v.String
+	case NullBool:
+		if isNull() {
+			return nil
+		}
+		return v.Bool
+	}
+	return bindArg(driver, item)
+}
+
+// stmtArgs convert arguments to the values bound to prepared statement
+func stmtArgs(driver dbDriver, argFmt argFormat, args []interface{}) []interface{} {
+	res := make([]interface{}, len(args))
+	for i, arg := range args {
+		res[i] = stmtArg(driver, argFmt, arg)
+	}
+	return res
+}
+
 // sql statement assemble
 func statementFormat(fmtStr string, argFunc argFormat, args ...interface{}) (string, error) {
 	if argFunc == nil {
@@ -530,34 +976,199 @@ func statementFormat(fmtStr string, argFunc argFormat, args ...interface{}) (str
 	return query, nil
 }
 
+// SplitStatements split sql script into statements separated by `;`, strings are escaped by backslash as mysql,
+// separators in strings, quoted identifiers, comments and dollar quoted bodies of postgresql are ignored,
+// `BEGIN ... END` blocks of triggers and procedures are kept in one statement,
+// the separator is changed by `DELIMITER` line as mysql client does, and empty statements are dropped
+func SplitStatements(script string) []string {
+	var stmts []string
+	delim := ";"
+	start, skip, depth, empty := 0, 0, 0, true
+	flush := func(end int) {
+		if !empty {
+			stmts = append(stmts, strings.TrimSpace(script[start:end]))
+		}
+	}
+	tokens := tokenize(script, driverMysql)
+	for i, t := range tokens {
+		if t.pos < skip || t.kind == tokenSpace || t.kind == tokenComment {
+			continue
+		}
+		if empty && t.is("DELIMITER") {
+			end := strings.IndexByte(script[t.pos:], '\n')
+			if end < 0 {
+				end = len(script)
+			} else {
+				end += t.pos
+			}
+			if d := strings.TrimSpace(script[t.pos+len(t.text) : end]); d != "" {
+				delim = d
+			}
+			start, skip = end, end
+			continue
+		}
+		if idx := delimIndex(script, t, delim); idx >= 0 && depth == 0 {
+			flush(idx)
+			start, skip, empty, depth = idx+len(delim), idx+len(delim), true, 0
+			continue
+		}
+		empty = false
+		if tag := dollarTag(t); tag != "" {
+			if end := strings.Index(script[t.pos+len(tag):], tag); end >= 0 {
+				skip = t.pos + len(tag) + end + len(tag)
+			} else {
+				skip = len(script)
+			}
+			continue
+		}
+		if delim == ";" && t.kind == tokenWord {
+			depth += blockDepth(tokens, i)
+			if depth < 0 {
+				depth = 0
+			}
+		}
+	}
+	flush(len(script))
+	return stmts
+}
+
+// offset of delimiter in token t, delimiter such as `$$` may be a part of word like `END$$`
+func delimIndex(script string, t token, delim string) int {
+	switch t.kind {
+	case tokenPunct:
+		if strings.HasPrefix(script[t.pos:], delim) {
+			return t.pos
+		}
+	case tokenWord:
+		if idx := strings.Index(t.text, delim); idx >= 0 {
+			return t.pos + idx
+		}
+	}
+	return -1
+}
+
+// tag of dollar quoted string of postgresql, such as `$$` or `$body$`
+func dollarTag(t token) string {
+	if t.kind != tokenWord || t.text[0] != '$' {
+		return ""
+	}
+	end := strings.IndexByte(t.text[1:], '$')
+	if end < 0 || end > 0 && t.text[1] >= '0' && t.text[1] <= '9' {
+		return ""
+	}
+	return t.text[:end+2]
+}
+
+// change of depth of BEGIN ... END blocks by the word token at i,
+// BEGIN of transaction, END IF and END LOOP of compound statements don't change it,
+// CASE is counted since CASE expression ends with END too
+func blockDepth(tokens []token, i int) int {
+	next := nextSignificant(tokens, i+1)
+	switch t := tokens[i]; {
+	case t.is("BEGIN"):
+		if next < 0 || tokens[next].kind != tokenWord {
+			return 0
+		}
+		for _, w := range []string{"TRANSACTION", "TRAN", "WORK", "DEFERRED", "IMMEDIATE", "EXCLUSIVE",
+			"ISOLATION", "READ", "DISTRIBUTED"} {
+			if tokens[next].is(w) {
+				return 0
+			}
+		}
+		return 1
+	case t.is("CASE"):
+		// CASE of END CASE
+		for j := i - 1; j >= 0; j-- {
+			if tokens[j].kind != tokenSpace && tokens[j].kind != tokenComment {
+				if tokens[j].is("END") {
+					return 0
+				}
+				break
+			}
+		}
+		return 1
+	case t.is("END"):
+		if next >= 0 {
+			for _, w := range []string{"IF", "LOOP", "WHILE", "REPEAT"} {
+				if tokens[next].is(w) {
+					return 0
+				}
+			}
+		}
+		return -1
+	}
+	return 0
+}
+
 // QueryFmtMysql sql statement assemble for mysql
 func QueryFmtMysql(fmtStr string, args ...interface{}) (string, error) {
 	return statementFormat(fmtStr, mysqlArgFormat, args...)
 }
 
 // format rows that insert into a table
-func multiRowsFmt(query string, argFunc argFormat, args [][]interface{}) (string, error) {
-	pat := `(\((\?,\s*)+\?*\s*\))`
-	r, _ := regexp.Compile(pat)
-	c := r.FindString(query)
-	if c == "" {
-		return "", ErrStatement
+func multiRowsFmt(driver dbDriver, query string, argFunc argFormat, args [][]interface{}) (string, error) {
+	qs, err := multiRowsChunkFmt(driver, query, argFunc, args, 0, 0)
+	if err != nil {
+		return "", err
+	}
+	return qs[0], nil
+}
+
+// format rows that insert into a table, split into chunks of statements,
+// each statement has maxRows rows at most, and its length is no more than maxBytes
+// unless it has only one row, zero means no limit
+func multiRowsChunkFmt(driver dbDriver, query string, argFunc argFormat, args [][]interface{},
+	maxRows, maxBytes int) ([]string, error) {
+	q, c, suffix, err := valuesTuple(driver, query)
+	if err != nil {
+		return nil, err
+	}
+	// placeholders are only allowed in values tuple
+	for _, t := range tokenize(suffix, driver) {
+		if t.kind == tokenPlaceholder {
+			return nil, ErrStatement
+		}
 	}
-	q := strings.Split(query, c)[0]
 
+	var qs []string
 	var items []string
+	size := len(q) + len(suffix) + 1
 	for _, arg := range args {
 		i, err := statementFormat(c, argFunc, arg...)
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+		if len(items) > 0 && ((maxRows > 0 && len(items) >= maxRows) || (maxBytes > 0 && size+len(i)+1 > maxBytes)) {
+			qs = append(qs, q+strings.Join(items, ",")+suffix+";")
+			items = nil
+			size = len(q) + len(suffix) + 1
+		}
+		if len(items) > 0 {
+			size++
 		}
 		items = append(items, i)
+		size += len(i)
 	}
-	q += strings.Join(items, ",") + ";"
-	return q, nil
+	qs = append(qs, q+strings.Join(items, ",")+suffix+";")
+	return qs, nil
 }
 
 // QueryFmtPostgresql sql statement assemble for postgresql
 func QueryFmtPostgresql(fmtStr string, args ...interface{}) (string, error) {
 	return statementFormat(fmtStr, pgArgFormat, args...)
 }
+
+// QueryFmtSqlite sql statement assemble for sqlite
+func QueryFmtSqlite(fmtStr string, args ...interface{}) (string, error) {
+	return statementFormat(fmtStr, sqliteArgFormat, args...)
+}
+
+// QueryFmtMssql sql statement assemble for sql server
+func QueryFmtMssql(fmtStr string, args ...interface{}) (string, error) {
+	return statementFormat(fmtStr, mssqlArgFormat, args...)
+}
+
+// QueryFmtClickhouse sql statement assemble for clickhouse
+func QueryFmtClickhouse(fmtStr string, args ...interface{}) (string, error) {
+	return statementFormat(fmtStr, clickhouseArgFormat, args...)
+}
@@ -1,7 +1,9 @@
 package sqly
 
 import (
+	"database/sql/driver"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -90,3 +92,189 @@ func TestQueryFmtPostgresql(t *testing.T) {
 	}
 	fmt.Println(res)
 }
+
+func TestArgFormat_PerHandle(t *testing.T) {
+	pg := newFakeDb(t, fakePostgres, "fake_postgres", driverPostgresql, nil)
+	my := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, nil)
+	// the handle opened later does not change the formatter of the former one
+	for _, c := range []struct {
+		db   *SqlY
+		want string
+	}{
+		{pg, "SELECT * FROM account WHERE deleted = 'f'"},
+		{my, "SELECT * FROM account WHERE deleted = 0"},
+	} {
+		res, err := c.db.Select().From("account").Where("deleted = ?", false).Format()
+		if err != nil || res != c.want {
+			t.Errorf("got %s %v, want %s", res, err, c.want)
+		}
+	}
+}
+
+// bound values of prepared statement are stored as the formatted statement does
+func TestMysql_UpdateManyBindArgs(t *testing.T) {
+	var bound []interface{}
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		for _, arg := range args {
+			bound = append(bound, arg.Value)
+		}
+		return &fakeResult{aff: 1}, nil
+	})
+	query := "UPDATE `account` SET `add_time`=?, `create_time`=?, `birthday`=?, `role`=? WHERE `id`=?"
+	row := []interface{}{time.Date(2021, 1, 2, 3, 4, 5, 0, time.FixedZone("CST", 8*3600)), time.Time{},
+		NullTime{}, NullInt64{Int64: 5}, 1}
+	if _, err := db.UpdateMany(query, [][]interface{}{row}); err != nil {
+		t.Fatal(err)
+	}
+	if len(bound) != len(row) || bound[0] != "2021-01-02 03:04:05.000000000" {
+		t.Fatalf("got %v", bound)
+	}
+	formatted, _ := QueryFmtMysql(query, row...)
+	if res, _ := QueryFmtMysql(query, bound...); res != formatted {
+		t.Errorf("got %s, want %s", res, formatted)
+	}
+}
+
+func TestMultiRowsChunkFmt(t *testing.T) {
+	query := "INSERT INTO `accounts` (`id`, `name`) VALUES (?, ?)"
+	args := [][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}}
+	qs, err := multiRowsChunkFmt(driverMysql, query, mysqlArgFormat, args, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qs) != 2 || qs[0] != "INSERT INTO `accounts` (`id`, `name`) VALUES (1, 'a'),(2, 'b');" ||
+		qs[1] != "INSERT INTO `accounts` (`id`, `name`) VALUES (3, 'c');" {
+		t.Errorf("got %v", qs)
+	}
+	// room for two rows
+	maxBytes := len(qs[0])
+	qs, err = multiRowsChunkFmt(driverMysql, query, mysqlArgFormat, args, 0, maxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qs) != 2 || len(qs[0]) > maxBytes {
+		t.Errorf("got %v", qs)
+	}
+	qs, err = multiRowsChunkFmt(driverMysql, query, mysqlArgFormat, args, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qs) != 3 {
+		t.Errorf("got %v", qs)
+	}
+}
+
+func TestMultiRowsFmt(t *testing.T) {
+	cases := []struct {
+		query string
+		args  [][]interface{}
+		res   string
+	}{
+		{
+			query: "INSERT INTO `accounts` (`name`) VALUES (?)",
+			args:  [][]interface{}{{"a"}, {"b"}},
+			res:   "INSERT INTO `accounts` (`name`) VALUES ('a'),('b');",
+		},
+		{
+			query: "INSERT INTO `accounts` (`id`, `create_time`, `name`) VALUES (?, NOW(), ?);",
+			args:  [][]interface{}{{1, "a"}, {2, "b"}},
+			res:   "INSERT INTO `accounts` (`id`, `create_time`, `name`) VALUES (1, NOW(), 'a'),(2, NOW(), 'b');",
+		},
+		{
+			query: "INSERT INTO `accounts` (`id`, `name`) VALUES(?,?) ON DUPLICATE KEY UPDATE `name`=VALUES(`name`);",
+			args:  [][]interface{}{{1, "a"}, {2, "b"}},
+			res:   "INSERT INTO `accounts` (`id`, `name`) VALUES(1,'a'),(2,'b') ON DUPLICATE KEY UPDATE `name`=VALUES(`name`);",
+		},
+		{
+			query: "insert into accounts (id, name) -- (?)\nvalues (?, ?)\nON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name",
+			args:  [][]interface{}{{1, "a"}},
+			res:   "insert into accounts (id, name) -- (?)\nvalues (1, 'a') ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name;",
+		},
+	}
+	for _, c := range cases {
+		res, err := multiRowsFmt(driverMysql, c.query, mysqlArgFormat, c.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res != c.res {
+			t.Errorf("got %s", res)
+		}
+	}
+
+	if _, err := multiRowsFmt(driverMysql, "INSERT INTO `accounts` SELECT * FROM `tmp`", mysqlArgFormat, nil); err != ErrStatement {
+		t.Errorf("expect statement error, got %v", err)
+	}
+	if _, err := multiRowsFmt(driverMysql, "INSERT INTO t (a) VALUES (?) ON DUPLICATE KEY UPDATE a=?", mysqlArgFormat,
+		[][]interface{}{{1, 2}}); err != ErrStatement {
+		t.Errorf("expect statement error, got %v", err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := "-- create table\nCREATE TABLE `a` (`id` INT, `name` VARCHAR(8) DEFAULT ';');\n\n" +
+		"INSERT INTO `a` VALUES (1, 'x;y'); /* ; */ ;\n-- the end\n"
+	cmp := []string{
+		"-- create table\nCREATE TABLE `a` (`id` INT, `name` VARCHAR(8) DEFAULT ';')",
+		"INSERT INTO `a` VALUES (1, 'x;y')",
+	}
+	stmts := SplitStatements(script)
+	if !reflect.DeepEqual(stmts, cmp) {
+		t.Errorf("got %q", stmts)
+	}
+	if stmts := SplitStatements("SELECT 1"); len(stmts) != 1 || stmts[0] != "SELECT 1" {
+		t.Errorf("got %q", stmts)
+	}
+
+	cases := []struct {
+		script string
+		stmts  []string
+	}{
+		{
+			// trigger of sqlite
+			script: "CREATE TRIGGER `t` AFTER INSERT ON `a` BEGIN\n" +
+				"  UPDATE `b` SET `n` = CASE WHEN `n` > 0 THEN `n` + 1 ELSE 1 END;\n" +
+				"  INSERT INTO `c` VALUES (NEW.`id`);\nEND;\nSELECT 1;",
+			stmts: []string{
+				"CREATE TRIGGER `t` AFTER INSERT ON `a` BEGIN\n" +
+					"  UPDATE `b` SET `n` = CASE WHEN `n` > 0 THEN `n` + 1 ELSE 1 END;\n" +
+					"  INSERT INTO `c` VALUES (NEW.`id`);\nEND",
+				"SELECT 1",
+			},
+		},
+		{
+			// procedure of mysql with compound statements
+			script: "CREATE PROCEDURE `p`() BEGIN\n" +
+				"  IF 1 THEN SELECT 1; END IF;\n" +
+				"  CASE WHEN 1 THEN SELECT 2; END CASE;\n" +
+				"  l: LOOP LEAVE l; END LOOP;\nEND;\nDROP TABLE `a`",
+			stmts: []string{
+				"CREATE PROCEDURE `p`() BEGIN\n" +
+					"  IF 1 THEN SELECT 1; END IF;\n" +
+					"  CASE WHEN 1 THEN SELECT 2; END CASE;\n" +
+					"  l: LOOP LEAVE l; END LOOP;\nEND",
+				"DROP TABLE `a`",
+			},
+		},
+		{
+			// delimiter of mysql client
+			script: "DELIMITER $$\nCREATE PROCEDURE `p`() BEGIN SELECT 1; END$$\nDELIMITER ;\nSELECT 2;",
+			stmts:  []string{"CREATE PROCEDURE `p`() BEGIN SELECT 1; END", "SELECT 2"},
+		},
+		{
+			// transactions and dollar quoted function body of postgresql
+			script: "BEGIN;\nCREATE FUNCTION f() RETURNS trigger AS $$ BEGIN NEW.n := 1; RETURN NEW; END; $$ LANGUAGE plpgsql;\n" +
+				"BEGIN TRANSACTION; END;",
+			stmts: []string{
+				"BEGIN",
+				"CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN NEW.n := 1; RETURN NEW; END; $$ LANGUAGE plpgsql",
+				"BEGIN TRANSACTION",
+				"END",
+			},
+		},
+	}
+	for _, c := range cases {
+		if stmts := SplitStatements(c.script); !reflect.DeepEqual(stmts, c.stmts) {
+			t.Errorf("got %q", stmts)
+		}
+	}
+}
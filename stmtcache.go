@@ -0,0 +1,210 @@
+package sqly
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// StmtCacheStats statistics of prepared statement cache
+type StmtCacheStats struct {
+	Size      int   // number of cached statements
+	Hits      int64 // times of statement found in cache
+	Misses    int64 // times of statement prepared
+	Evictions int64 // times of statement evicted or invalidated
+}
+
+type prepareFunc func(ctx context.Context, query string) (*sql.Stmt, error)
+
+// cached statement, it's closed when it's evicted and not in use
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtCache LRU cache of prepared statements keyed by sql text
+type stmtCache struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	items   map[string]*list.Element
+	prepare prepareFunc
+	parent  *stmtCache // cache of SqlY which statements of transaction are rebound from
+	stats   StmtCacheStats
+}
+
+func newStmtCache(size int, prepare prepareFunc) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+		prepare: prepare,
+	}
+}
+
+// acquire statement of query from cache, or prepare it, call release after use
+func (c *stmtCache) acquire(ctx context.Context, query string) (*stmtEntry, error) {
+	c.mu.Lock()
+	if el, ok := c.items[query]; ok {
+		c.ll.MoveToFront(el)
+		e := el.Value.(*stmtEntry)
+		e.refs++
+		c.stats.Hits++
+		c.mu.Unlock()
+		return e, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	stmt, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// prepared by others at the same time
+	if el, ok := c.items[query]; ok {
+		_ = stmt.Close()
+		c.ll.MoveToFront(el)
+		e := el.Value.(*stmtEntry)
+		e.refs++
+		return e, nil
+	}
+	e := &stmtEntry{query: query, stmt: stmt, refs: 1}
+	c.items[query] = c.ll.PushFront(e)
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+	return e, nil
+}
+
+// release statement after use
+func (c *stmtCache) release(e *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.refs--
+	if e.evicted && e.refs == 0 {
+		_ = e.stmt.Close()
+	}
+}
+
+// remove element from cache, the statement is closed if it's not in use, lock is held
+func (c *stmtCache) remove(el *list.Element) {
+	e := c.ll.Remove(el).(*stmtEntry)
+	delete(c.items, e.query)
+	e.evicted = true
+	c.stats.Evictions++
+	if e.refs == 0 {
+		_ = e.stmt.Close()
+	}
+}
+
+// invalidate the statement of query
+func (c *stmtCache) invalidate(query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[query]; ok {
+		c.remove(el)
+	}
+}
+
+// close all statements
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.ll.Len() > 0 {
+		c.remove(c.ll.Back())
+	}
+}
+
+// statistics of cache
+func (c *stmtCache) statistics() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.ll.Len()
+	return stats
+}
+
+// the prepared statement should be prepared again, such as the table is altered
+func isStmtInvalid(err error) bool {
+	msg := err.Error()
+	for _, s := range []string{
+		"needs to be re-prepared",                 // mysql 1615
+		"Unknown prepared statement",              // mysql 1243
+		"cached plan must not change result type", // postgresql
+		"statement is closed",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// run f with statement of query, the statement is prepared again once if it's invalid
+func (c *stmtCache) do(ctx context.Context, query string, f func(stmt *sql.Stmt) error) error {
+	for i := 0; ; i++ {
+		e, err := c.acquire(ctx, query)
+		if err != nil {
+			return err
+		}
+		err = f(e.stmt)
+		c.release(e)
+		if err == nil || !isStmtInvalid(err) {
+			return err
+		}
+		c.invalidate(query)
+		// statement of transaction is rebound from the parent one, which is invalid too
+		if c.parent != nil {
+			c.parent.invalidate(query)
+		}
+		if i > 0 {
+			return err
+		}
+	}
+}
+
+// query with cached statement
+func (c *stmtCache) query(ctx context.Context, query string, args []interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := c.do(ctx, query, func(stmt *sql.Stmt) error {
+		var err error
+		rows, err = stmt.QueryContext(ctx, args...)
+		return err
+	})
+	return rows, err
+}
+
+// exec with cached statement
+func (c *stmtCache) exec(ctx context.Context, query string, args []interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := c.do(ctx, query, func(stmt *sql.Stmt) error {
+		var err error
+		res, err = stmt.ExecContext(ctx, args...)
+		return err
+	})
+	return res, err
+}
+
+// arguments can be sent as bind args, arrays are expanded by formatting the statement
+func bindAble(driver dbDriver, args []interface{}) bool {
+	if driver == driverClickhouse {
+		return false
+	}
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		t := reflect.TypeOf(arg)
+		if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSqlite_StmtCache(t *testing.T) {
+	db := newSqliteDb(t)
+	defer db.Close()
+	db.EnableStmtCache(2)
+	ctx := context.TODO()
+
+	insert := "INSERT INTO `account` (`nickname`, `mobile`, `role`) VALUES (?, ?, ?)"
+	for i, mobile := range []string{"18812311231", "18812311232", "18812311233"} {
+		if _, err := db.InsertCtx(ctx, insert, "lucy", mobile, NullInt32{Int32: int32(i), Valid: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var acc Account
+	if err := db.GetCtx(ctx, &acc, "SELECT * FROM `account` WHERE `mobile`=?", "18812311233"); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Role.Int32 != 2 {
+		t.Errorf("unexpected account %+v", acc)
+	}
+	stats := db.StmtCacheStats()
+	if stats.Size != 2 || stats.Hits != 2 || stats.Misses != 2 || stats.Evictions != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// array argument is expanded by formatting, not cached
+	var accs []*Account
+	if err := db.Query(&accs, "SELECT * FROM `account` WHERE `mobile` IN ?", []string{"18812311231", "18812311232"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(accs) != 2 {
+		t.Errorf("got %d accounts", len(accs))
+	}
+	if db.StmtCacheStats().Misses != 2 {
+		t.Errorf("array argument should not be prepared")
+	}
+
+	// the least recently used is evicted
+	if _, err := db.Update("UPDATE `account` SET `role`=? WHERE `mobile`=?", 5, "18812311231"); err != nil {
+		t.Fatal(err)
+	}
+	if stats := db.StmtCacheStats(); stats.Size != 2 || stats.Evictions != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// statements are rebound in transaction
+	_, err := db.Transaction(func(tx *Trans) (interface{}, error) {
+		for _, mobile := range []string{"18812311232", "18812311233"} {
+			if _, err := tx.UpdateCtx(ctx, "UPDATE `account` SET `role`=? WHERE `mobile`=?", 5, mobile); err != nil {
+				return nil, err
+			}
+		}
+		if stats := tx.StmtCacheStats(); stats.Hits != 1 || stats.Misses != 1 {
+			t.Errorf("unexpected stats of transaction %+v", stats)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := db.Get(&count, "SELECT COUNT(*) FROM `account` WHERE `role`=?", 5); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("got %d rows updated", count)
+	}
+}
+
+func TestMysql_StmtCacheInvalidate(t *testing.T) {
+	failed := false
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if !failed {
+			failed = true
+			return nil, errors.New("Error 1615: Prepared statement needs to be re-prepared")
+		}
+		return &fakeResult{aff: 1}, nil
+	})
+	db.EnableStmtCache(4)
+	defer db.EnableStmtCache(0)
+	aff, err := db.Exec("UPDATE `account` SET `role`=? WHERE `id`=?", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := aff.GetRowsAffected(); n != 1 {
+		t.Errorf("rows affected %d", n)
+	}
+	if stats := db.StmtCacheStats(); stats.Misses != 2 || stats.Evictions != 1 || stats.Size != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMysql_StmtCacheInvalidateTrans(t *testing.T) {
+	failed := false
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if strings.HasPrefix(query, "UPDATE") && !failed {
+			failed = true
+			return nil, errors.New("Error 1615: Prepared statement needs to be re-prepared")
+		}
+		return &fakeResult{aff: 1}, nil
+	})
+	db.EnableStmtCache(4)
+	defer db.EnableStmtCache(0)
+	_, err := db.Transaction(func(tx *Trans) (interface{}, error) {
+		return tx.Exec("UPDATE `account` SET `role`=? WHERE `id`=?", 1, 2)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// statement of SqlY is prepared again, the transaction does not rebind the stale one
+	if stats := db.StmtCacheStats(); stats.Misses != 2 || stats.Evictions != 1 || stats.Size != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+// enabling the cache does not change the values stored
+func TestMysql_StmtCacheArgs(t *testing.T) {
+	var last string
+	var bound []interface{}
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		if !strings.HasPrefix(query, "UPDATE") {
+			return &fakeResult{}, nil
+		}
+		last, bound = query, nil
+		for _, arg := range args {
+			bound = append(bound, arg.Value)
+		}
+		return &fakeResult{aff: 1}, nil
+	})
+	query := "UPDATE `account` SET `add_time`=?, `create_time`=?, `birthday`=?, `role`=?, `stature`=? WHERE `id`=?"
+	args := []interface{}{time.Date(2021, 1, 2, 3, 4, 5, 0, time.FixedZone("CST", 8*3600)), time.Time{},
+		NullTime{}, NullInt64{Int64: 5}, NullFloat64{}, 1}
+	for _, inTrans := range []bool{false, true} {
+		exec := func() {
+			var err error
+			if inTrans {
+				_, err = db.Transaction(func(tx *Trans) (interface{}, error) {
+					return tx.Exec(query, args...)
+				})
+			} else {
+				_, err = db.Exec(query, args...)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		db.EnableStmtCache(0)
+		exec()
+		formatted := last
+		db.EnableStmtCache(4)
+		exec()
+		// time is bound as text, not converted by location of driver
+		if len(bound) != len(args) || bound[0] != "2021-01-02 03:04:05.000000000" {
+			t.Fatalf("got %s %v", last, bound)
+		}
+		if res, _ := QueryFmtMysql(query, bound...); res != formatted {
+			t.Errorf("got %s, want %s", res, formatted)
+		}
+	}
+	db.EnableStmtCache(0)
+}
@@ -0,0 +1,184 @@
+package sqly
+
+import (
+	"strings"
+)
+
+type tokenKind int8
+
+const (
+	tokenSpace       tokenKind = iota // white spaces
+	tokenComment                      // -- comment, /* comment */
+	tokenWord                         // keyword, identifier, number
+	tokenString                       // 'string'
+	tokenQuoted                       // `identifier`, "identifier", [identifier]
+	tokenPlaceholder                  // ?
+	tokenPunct                        // ( ) , ; and operators
+)
+
+// token of sql statement
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // offset in statement
+}
+
+// is keyword (case insensitive)
+func (t token) is(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// is punctuation
+func (t token) isPunct(p string) bool {
+	return t.kind == tokenPunct && t.text == p
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c == '@' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// index of the end of quoted text start at i, the quote is escaped by doubling it,
+// and backslash escapes the next char in string
+func quotedEnd(query string, i int, quote byte, backslash bool) int {
+	for j := i + 1; j < len(query); j++ {
+		switch {
+		case backslash && query[j] == '\\':
+			j++
+		case query[j] == quote:
+			if j+1 < len(query) && query[j+1] == quote && quote != ']' {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+// backslash escapes the next char in string literals of driver,
+// postgresql only escapes in E'...' strings, sqlite and sql server never do
+func backslashEscape(driver dbDriver) bool {
+	switch driver {
+	case driverMysql, driverClickhouse, driverOthers:
+		return true
+	}
+	return false
+}
+
+// tokenize split sql statement of driver into tokens,
+// sql server quotes identifier with brackets, and strings are escaped as backslashEscape
+func tokenize(query string, driver dbDriver) []token {
+	brackets, backslash := driver == driverMssql, backslashEscape(driver)
+	var tokens []token
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		var kind tokenKind
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			kind = tokenSpace
+			for i < len(query) && strings.IndexByte(" \t\n\r", query[i]) >= 0 {
+				i++
+			}
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			kind = tokenComment
+			if j := strings.IndexByte(query[i:], '\n'); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(query)
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			kind = tokenComment
+			if j := strings.Index(query[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(query)
+			}
+		case c == '\'':
+			kind = tokenString
+			i = quotedEnd(query, i, '\'', backslash)
+		case (c == 'E' || c == 'e') && driver == driverPostgresql && i+1 < len(query) && query[i+1] == '\'' &&
+			(i == 0 || !isWordChar(query[i-1])):
+			// escape string of postgresql
+			kind = tokenString
+			i = quotedEnd(query, i+1, '\'', true)
+		case c == '`' || c == '"':
+			kind = tokenQuoted
+			i = quotedEnd(query, i, c, false)
+		case c == '[' && brackets:
+			kind = tokenQuoted
+			i = quotedEnd(query, i, ']', false)
+		case c == '?':
+			kind = tokenPlaceholder
+			i++
+		case isWordChar(c):
+			kind = tokenWord
+			for i < len(query) && isWordChar(query[i]) {
+				i++
+			}
+		default:
+			kind = tokenPunct
+			i++
+		}
+		tokens = append(tokens, token{kind: kind, text: query[start:i], pos: start})
+	}
+	return tokens
+}
+
+// index of the next token which is not space or comment, from i
+func nextSignificant(tokens []token, i int) int {
+	for ; i < len(tokens); i++ {
+		if tokens[i].kind != tokenSpace && tokens[i].kind != tokenComment {
+			return i
+		}
+	}
+	return -1
+}
+
+// index of the token that closes the parenthesis opened at i
+func closeParen(tokens []token, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		if tokens[i].isPunct("(") {
+			depth++
+		} else if tokens[i].isPunct(")") {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// valuesTuple split insert statement into the part before values tuple, the tuple and the part after it,
+// such as `INSERT INTO t (a, b) VALUES `, `(?, NOW(), ?)` and ` ON DUPLICATE KEY UPDATE b=VALUES(b)`
+func valuesTuple(driver dbDriver, query string) (prefix, tuple, suffix string, err error) {
+	tokens := tokenize(query, driver)
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+		case depth == 0 && (t.is("VALUES") || t.is("VALUE")):
+			open := nextSignificant(tokens, i+1)
+			if open < 0 || !tokens[open].isPunct("(") {
+				return "", "", "", ErrStatement
+			}
+			end := closeParen(tokens, open)
+			if end < 0 {
+				return "", "", "", ErrStatement
+			}
+			start, stop := tokens[open].pos, tokens[end].pos+1
+			suffix = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(query[stop:]), ";"))
+			if suffix != "" {
+				suffix = " " + suffix
+			}
+			return query[:start], query[start:stop], suffix, nil
+		}
+	}
+	return "", "", "", ErrStatement
+}
@@ -0,0 +1,156 @@
+package sqly
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Tracker records snapshots of rows, UpdateChanged updates the fields modified since Track only.
+// it's owned by caller, such as one tracker per request, and the snapshots are released with it
+type Tracker struct {
+	mu   sync.Mutex
+	rows map[interface{}]map[string]interface{} // keyed by struct pointer
+}
+
+// NewTracker new tracker of rows
+func NewTracker() *Tracker {
+	return &Tracker{rows: make(map[interface{}]map[string]interface{})}
+}
+
+// deep copy of value, so in-place modifications of slices, maps and pointers are detected
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		if v.Kind() == reflect.Ptr {
+			c.Set(reflect.New(v.Elem().Type()))
+			c.Elem().Set(deepCopy(v.Elem()))
+		} else {
+			c.Set(deepCopy(v.Elem()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		// unexported fields are shallow copied
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
+
+// copy of struct field values, keyed by column
+func snapshot(v reflect.Value) map[string]interface{} {
+	snap := make(map[string]interface{})
+	for _, m := range structFieldsMeta(v.Elem().Type()) {
+		if fv := fieldValue(v, m.pos); fv.IsValid() {
+			snap[m.column] = deepCopy(fv).Interface()
+		} else {
+			snap[m.column] = nil
+		}
+	}
+	return snap
+}
+
+// Track record the field values of row (struct pointer), such as after a Get,
+// UpdateChanged updates the modified fields only
+func (tr *Tracker) Track(row interface{}) error {
+	v, err := structPtrValue(row)
+	if err != nil {
+		return err
+	}
+	tr.mu.Lock()
+	tr.rows[row] = snapshot(v)
+	tr.mu.Unlock()
+	return nil
+}
+
+// Untrack release the snapshot of row
+func (tr *Tracker) Untrack(row interface{}) {
+	tr.mu.Lock()
+	delete(tr.rows, row)
+	tr.mu.Unlock()
+}
+
+// update the fields of row which are modified since Track, keyed by primary keys of the snapshot,
+// the snapshot is refreshed after update
+func updateChanged(ctx context.Context, q executor, driver dbDriver, argFmt argFormat, tr *Tracker, table string,
+	row interface{}) (*Affected, error) {
+	v, err := structPtrValue(row)
+	if err != nil {
+		return nil, err
+	}
+	tr.mu.Lock()
+	old, ok := tr.rows[row]
+	tr.mu.Unlock()
+	if !ok {
+		return nil, ErrNotTracked
+	}
+	cur := snapshot(v)
+	var sets, conds []string
+	var args, pkArgs []interface{}
+	for _, m := range structFieldsMeta(v.Elem().Type()) {
+		if m.pk {
+			conds = append(conds, quoteIdent(driver, m.column)+"=?")
+			pkArgs = append(pkArgs, old[m.column])
+		}
+		if !reflect.DeepEqual(old[m.column], cur[m.column]) {
+			sets = append(sets, quoteIdent(driver, m.column)+"=?")
+			args = append(args, cur[m.column])
+		}
+	}
+	if len(conds) == 0 {
+		return nil, ErrNoPrimaryKey
+	}
+	// nothing changed
+	if len(sets) == 0 {
+		return &Affected{driver: driver}, nil
+	}
+	query, err := statementFormat("UPDATE "+quoteIdent(driver, table)+" SET "+strings.Join(sets, ", ")+
+		" WHERE "+strings.Join(conds, " AND "), argFmt, append(args, pkArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := q.ExecContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	tr.mu.Lock()
+	tr.rows[row] = cur
+	tr.mu.Unlock()
+	return &Affected{result: res, driver: driver}, nil
+}
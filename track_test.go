@@ -0,0 +1,97 @@
+package sqly
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestMysql_UpdateChanged(t *testing.T) {
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{aff: 1}, nil
+	})
+	type account struct {
+		ID       int64     `sql:"id,pk"`
+		Nickname string    `sql:"nickname"`
+		Mobile   string    `sql:"mobile"`
+		Role     NullInt32 `sql:"role"`
+	}
+	ctx := context.TODO()
+	tr := NewTracker()
+	acc := &account{ID: 3, Nickname: "lucy", Mobile: "18812311231"}
+	if _, err := db.UpdateChanged(ctx, tr, "account", acc); err != ErrNotTracked {
+		t.Errorf("expect ErrNotTracked, got %v", err)
+	}
+	if err := tr.Track(acc); err != nil {
+		t.Fatal(err)
+	}
+	// nothing changed
+	aff, err := db.UpdateChanged(ctx, tr, "account", acc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := aff.GetRowsAffected(); n != 0 {
+		t.Errorf("rows affected %d", n)
+	}
+	if qs := fakeMysql.recorded(); len(qs) != 0 {
+		t.Errorf("unexpected statements %v", qs)
+	}
+
+	acc.Nickname = "lily"
+	acc.Role = NullInt32{Int32: 2, Valid: true}
+	if _, err := db.UpdateChanged(ctx, tr, "account", acc); err != nil {
+		t.Fatal(err)
+	}
+	// snapshot is refreshed
+	acc.Mobile = "18812311232"
+	if _, err := db.UpdateChanged(ctx, tr, "account", acc); err != nil {
+		t.Fatal(err)
+	}
+	qs := fakeMysql.recorded()
+	cmp := []string{
+		"UPDATE `account` SET `nickname`='lily', `role`=2 WHERE `id`=3",
+		"UPDATE `account` SET `mobile`='18812311232' WHERE `id`=3",
+	}
+	if len(qs) != 2 || qs[0] != cmp[0] || qs[1] != cmp[1] {
+		t.Errorf("got %v", qs)
+	}
+
+	tr.Untrack(acc)
+	if _, err := db.UpdateChanged(ctx, tr, "account", acc); err != ErrNotTracked {
+		t.Errorf("expect ErrNotTracked, got %v", err)
+	}
+}
+
+func TestMysql_UpdateChangedSlice(t *testing.T) {
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{aff: 1}, nil
+	})
+	type account struct {
+		ID     int64       `sql:"id,pk"`
+		Avatar []byte      `sql:"avatar"`
+		Tags   StringArray `sql:"tags"`
+	}
+	ctx := context.TODO()
+	tr := NewTracker()
+	acc := &account{ID: 3, Avatar: []byte("a.png"), Tags: StringArray{"a", "b"}}
+	if err := tr.Track(acc); err != nil {
+		t.Fatal(err)
+	}
+	// modified in place, the backing arrays are shared with the tracked values
+	acc.Avatar[0] = 'b'
+	if _, err := db.UpdateChanged(ctx, tr, "account", acc); err != nil {
+		t.Fatal(err)
+	}
+	acc.Tags[1] = "c"
+	if _, err := db.UpdateChanged(ctx, tr, "account", acc); err != nil {
+		t.Fatal(err)
+	}
+	qs := fakeMysql.recorded()
+	cmp := []string{
+		"UPDATE `account` SET `avatar`='b.png' WHERE `id`=3",
+		"UPDATE `account` SET `tags`='{\"a\",\"c\"}' WHERE `id`=3",
+	}
+	if len(qs) != 2 || qs[0] != cmp[0] || qs[1] != cmp[1] {
+		t.Errorf("got %v", qs)
+	}
+}
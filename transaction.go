@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
+	"reflect"
 	"strings"
 )
 
@@ -12,6 +12,53 @@ import (
 type Trans struct {
 	tx     *sql.Tx
 	driver dbDriver
+	sqlY   *SqlY
+	stmts  *stmtCache
+}
+
+// statements cached by SqlY are rebound to transaction
+func (t *Trans) initStmtCache() {
+	if t.sqlY.stmts == nil {
+		return
+	}
+	parent := t.sqlY.stmts
+	t.stmts = newStmtCache(parent.size, func(ctx context.Context, query string) (*sql.Stmt, error) {
+		e, err := parent.acquire(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		defer parent.release(e)
+		return t.tx.StmtContext(ctx, e.stmt), nil
+	})
+	t.stmts.parent = parent
+}
+
+// query with args, by cached prepared statement if it's enabled
+func (t *Trans) queryArgs(ctx context.Context, query string, args []interface{}) (*sql.Rows, error) {
+	if t.stmts != nil && bindAble(t.driver, args) {
+		return t.stmts.query(ctx, rebind(t.driver, query), stmtArgs(t.driver, t.sqlY.argFmt, args))
+	}
+	q, err := statementFormat(query, t.sqlY.argFmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	return t.tx.QueryContext(ctx, q)
+}
+
+// exec with args, by cached prepared statement if it's enabled
+func (t *Trans) execArgs(ctx context.Context, query string, args []interface{}) (*Affected, error) {
+	if t.stmts != nil && bindAble(t.driver, args) {
+		res, err := t.stmts.exec(ctx, rebind(t.driver, query), stmtArgs(t.driver, t.sqlY.argFmt, args))
+		if err != nil {
+			return nil, err
+		}
+		return &Affected{result: res, driver: t.driver}, nil
+	}
+	q, err := statementFormat(query, t.sqlY.argFmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	return t.execOneTx(ctx, q)
 }
 
 // exec one sql statement with context
@@ -38,6 +85,89 @@ func execManyTx(ctx context.Context, tx *sql.Tx, queries []string) error {
 	return nil
 }
 
+// exec chunks of insert statements, rows affected are summed,
+// and the result of first chunk is kept for LastInsertId
+func execChunksTx(ctx context.Context, tx *sql.Tx, driver dbDriver, queries []string) (*Affected, error) {
+	aff := &Affected{driver: driver}
+	for _, query := range queries {
+		res, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if aff.result == nil {
+			aff.result = res
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		aff.rowsAffected += n
+	}
+	return aff, nil
+}
+
+// exec prepared statement with each row of args, the `?` placeholders are bound to args,
+// rows that can't be bound (such as slice for `IN ?`) are formatted into statement
+func execEachTx(ctx context.Context, tx *sql.Tx, driver dbDriver, argFmt argFormat, query string,
+	args [][]interface{}) (*Affected, error) {
+	var stmt *sql.Stmt
+	defer func() {
+		if stmt != nil {
+			_ = stmt.Close()
+		}
+	}()
+	aff := &Affected{driver: driver, rowsAffectedList: make([]int64, 0, len(args))}
+	for _, arg := range args {
+		var res sql.Result
+		var err error
+		if bindAble(driver, arg) {
+			if stmt == nil {
+				if stmt, err = tx.PrepareContext(ctx, rebind(driver, query)); err != nil {
+					return nil, err
+				}
+			}
+			res, err = stmt.ExecContext(ctx, stmtArgs(driver, argFmt, arg)...)
+		} else {
+			var q string
+			if q, err = statementFormat(query, argFmt, arg...); err != nil {
+				return nil, err
+			}
+			res, err = tx.ExecContext(ctx, q)
+		}
+		if err != nil {
+			return nil, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		aff.rowsAffected += n
+		aff.rowsAffectedList = append(aff.rowsAffectedList, n)
+	}
+	return aff, nil
+}
+
+// insert rows in batch with prepared statement, for clickhouse
+// the rows are sent to server when the transaction is committed
+func execBatchTx(ctx context.Context, tx *sql.Tx, driver dbDriver, query string, args [][]interface{}) (*Affected, error) {
+	stmt, err := tx.PrepareContext(ctx, strings.TrimRight(strings.TrimSpace(query), ";"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+	for _, arg := range args {
+		if _, err := stmt.ExecContext(ctx, bindArgs(driver, arg)...); err != nil {
+			return nil, err
+		}
+	}
+	return &Affected{
+		rowsAffected: -1,
+		driver:       driver,
+	}, nil
+}
+
 // Rollback abort transaction
 func (t *Trans) Rollback() error {
 	return t.tx.Rollback()
@@ -50,95 +180,42 @@ func (t *Trans) Commit() error {
 
 // Query query results
 func (t *Trans) Query(dest interface{}, query string, args ...interface{}) error {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		if errors.Is(err, ErrEmptyArrayInStatement) {
-			return nil
-		}
-		return err
-	}
-	// query db
-	rows, err := t.tx.Query(q)
-	if err != nil {
-		return err
-	}
-	return checkAllV2(rows, dest)
+	return t.QueryCtx(context.Background(), dest, query, args...)
 }
 
 // Get query one row
 func (t *Trans) Get(dest interface{}, query string, args ...interface{}) error {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		if errors.Is(err, ErrEmptyArrayInStatement) {
-			return nil
-		}
-		return err
-	}
-	// query db
-	rows, err := t.tx.Query(q)
-	if err != nil {
-		return err
-	}
-	return checkOneV2(rows, dest)
+	return t.GetCtx(context.Background(), dest, query, args...)
 }
 
 // Insert insert
 func (t *Trans) Insert(query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return t.execOneTx(context.Background(), q)
+	return t.InsertCtx(context.Background(), query, args...)
 }
 
 // InsertMany insert many rows
 func (t *Trans) InsertMany(query string, args [][]interface{}) (*Affected, error) {
-	q, err := multiRowsFmt(query, argFmtFunc, args)
-	if err != nil {
-		return nil, err
-	}
-	return t.execOneTx(context.Background(), q)
+	return t.InsertManyCtx(context.Background(), query, args)
 }
 
 // Update update
 func (t *Trans) Update(query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return t.execOneTx(context.Background(), q)
+	return t.UpdateCtx(context.Background(), query, args...)
 }
 
 // UpdateMany update many
 func (t *Trans) UpdateMany(query string, args [][]interface{}) (*Affected, error) {
-	var qs []string
-	for _, arg := range args {
-		t, err := statementFormat(query, argFmtFunc, arg...)
-		if err != nil {
-			return nil, err
-		}
-		qs = append(qs, t)
-	}
-	q := strings.Join(qs, ";")
-	return t.execOneTx(context.Background(), q)
+	return t.UpdateManyCtx(context.Background(), query, args)
 }
 
 // Delete delete
 func (t *Trans) Delete(query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return t.execOneTx(context.Background(), q)
+	return t.DeleteCtx(context.Background(), query, args...)
 }
 
 // Exec general sql statement execute
 func (t *Trans) Exec(query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return t.execOneTx(context.Background(), q)
+	return t.ExecCtx(context.Background(), query, args...)
 }
 
 // ExecMany execute multi sql statement
@@ -148,94 +225,67 @@ func (t *Trans) ExecMany(queries []string) error {
 
 // QueryCtx query results
 func (t *Trans) QueryCtx(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	q, err := statementFormat(query, argFmtFunc, args...)
+	rows, err := t.queryArgs(ctx, query, args)
 	if err != nil {
 		if errors.Is(err, ErrEmptyArrayInStatement) {
 			return nil
 		}
 		return err
 	}
-	// query db
-	rows, err := t.tx.QueryContext(ctx, q)
-	if err != nil {
-		return err
-	}
 	return checkAllV2(rows, dest)
 }
 
 // GetCtx query one row
 func (t *Trans) GetCtx(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	q, err := statementFormat(query, argFmtFunc, args...)
+	rows, err := t.queryArgs(ctx, query, args)
 	if err != nil {
 		if errors.Is(err, ErrEmptyArrayInStatement) {
 			return nil
 		}
 		return err
 	}
-	// query db
-	rows, err := t.tx.QueryContext(ctx, q)
-	if err != nil {
-		return err
-	}
 	return checkOneV2(rows, dest)
 }
 
 // InsertCtx insert
 func (t *Trans) InsertCtx(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return t.execOneTx(ctx, q)
+	return t.execArgs(ctx, query, args)
 }
 
-// InsertManyCtx insert many rows
+// InsertManyCtx insert many rows, split into chunks as SqlY.InsertManyCtx
+// for clickhouse, rows are sent in one batch, and rows affected is not supported
 func (t *Trans) InsertManyCtx(ctx context.Context, query string, args [][]interface{}) (*Affected, error) {
-	q, err := multiRowsFmt(query, argFmtFunc, args)
+	if t.driver == driverClickhouse {
+		return execBatchTx(ctx, t.tx, t.driver, query, args)
+	}
+	qs, err := multiRowsChunkFmt(t.driver, query, t.sqlY.argFmt, args, t.sqlY.chunkRows, t.sqlY.chunkBytes)
 	if err != nil {
 		return nil, err
 	}
-	return t.execOneTx(ctx, q)
+	if len(qs) == 1 {
+		return t.execOneTx(ctx, qs[0])
+	}
+	return execChunksTx(ctx, t.tx, t.driver, qs)
 }
 
 // UpdateCtx update
 func (t *Trans) UpdateCtx(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return t.execOneTx(ctx, q)
+	return t.execArgs(ctx, query, args)
 }
 
-// UpdateManyCtx update many trans
+// UpdateManyCtx update many trans, the query is prepared once and executed with each row of args
 func (t *Trans) UpdateManyCtx(ctx context.Context, query string, args [][]interface{}) (*Affected, error) {
-	var q string
-	for _, arg := range args {
-		tmp, err := statementFormat(query, argFmtFunc, arg...)
-		if err != nil {
-			return nil, err
-		}
-		q += tmp + ";"
-	}
-	return t.execOneTx(ctx, q)
+	return execEachTx(ctx, t.tx, t.driver, t.sqlY.argFmt, query, args)
 }
 
 // DeleteCtx delete
 func (t *Trans) DeleteCtx(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return t.execOneTx(ctx, q)
+	return t.execArgs(ctx, query, args)
 }
 
 // ExecCtx general sql statement execute
 func (t *Trans) ExecCtx(ctx context.Context, query string, args ...interface{}) (*Affected, error) {
-	q, err := statementFormat(query, argFmtFunc, args...)
-	if err != nil {
-		return nil, err
-	}
-	return t.execOneTx(ctx, q)
+	return t.execArgs(ctx, query, args)
 }
 
 // ExecManyCtx execute multi sql statement
@@ -250,14 +300,14 @@ func (t *Trans) PgExec(idField, query string, args ...interface{}) (*Affected, e
 
 // PgExecCtx execute  statement for postgresql with context
 func (t *Trans) PgExecCtx(ctx context.Context, idField, query string, args ...interface{}) (*Affected, error) {
-	if t.driver != driverPostgresql {
+	if t.driver != driverPostgresql && t.driver != driverSqlite && t.driver != driverMssql {
 		return nil, ErrNotSupportForThisDriver
 	}
-	q, err := statementFormat(query, argFmtFunc, args...)
+	q, err := statementFormat(query, t.sqlY.argFmt, args...)
 	if err != nil {
 		return nil, err
 	}
-	q = fmt.Sprintf("%s RETURNING %s", q, idField)
+	q = returningFmt(t.driver, q, idField)
 	var id int64
 	err = t.tx.QueryRowContext(ctx, q).Scan(&id)
 	if err != nil {
@@ -269,3 +319,143 @@ func (t *Trans) PgExecCtx(ctx context.Context, idField, query string, args ...in
 		driver:       t.driver,
 	}, nil
 }
+
+// ExecReturning execute statement with RETURNING clause, and scan the returned rows into dest
+func (t *Trans) ExecReturning(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ok, err := t.sqlY.server.supportReturning(ctx, t.tx, t.driver)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotSupportForThisDriver
+	}
+	q, err := statementFormat(query, t.sqlY.argFmt, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := t.tx.QueryContext(ctx, returningAllFmt(t.driver, q))
+	if err != nil {
+		return err
+	}
+	return checkDest(rows, dest)
+}
+
+// InsertManyReturningIDs insert many rows, and return all the generated ids in order of rows
+func (t *Trans) InsertManyReturningIDs(ctx context.Context, idField, query string, args [][]interface{}) ([]int64, error) {
+	return insertManyReturningIDs(ctx, t.tx, t.driver, t.sqlY.argFmt, idField, query, args)
+}
+
+// Upsert insert row, or update it on conflict of conflictCols
+func (t *Trans) Upsert(ctx context.Context, table string, conflictCols []string, row interface{},
+	updateCols ...string) (UpsertStatus, error) {
+	return upsertOne(ctx, t.tx, t.driver, t.sqlY.argFmt, table, conflictCols, row, updateCols)
+}
+
+// UpsertMany upsert rows in one statement
+func (t *Trans) UpsertMany(ctx context.Context, table string, conflictCols []string, rows interface{},
+	updateCols ...string) ([]UpsertStatus, error) {
+	return upsertMany(ctx, t.tx, t.driver, t.sqlY.argFmt, table, conflictCols, rows, updateCols)
+}
+
+// InsertStruct insert row (struct pointer) into table, the generated id is set to row
+func (t *Trans) InsertStruct(ctx context.Context, table string, row interface{}) (*Affected, error) {
+	return insertStruct(ctx, t.tx, t.driver, t.sqlY.argFmt, table, row)
+}
+
+// UpdateStruct update all columns of row (struct pointer), keyed by primary keys
+func (t *Trans) UpdateStruct(ctx context.Context, table string, row interface{}) (*Affected, error) {
+	return updateStruct(ctx, t.tx, t.driver, t.sqlY.argFmt, table, row)
+}
+
+// DeleteByPK delete row (struct pointer) keyed by primary keys
+func (t *Trans) DeleteByPK(ctx context.Context, table string, row interface{}) (*Affected, error) {
+	return deleteByPK(ctx, t.tx, t.driver, t.sqlY.argFmt, table, row)
+}
+
+// UpdateChanged update the fields of row (struct pointer) which are modified since tr.Track
+func (t *Trans) UpdateChanged(ctx context.Context, tr *Tracker, table string, row interface{}) (*Affected, error) {
+	return updateChanged(ctx, t.tx, t.driver, t.sqlY.argFmt, tr, table, row)
+}
+
+// Select build select statement queried in transaction
+func (t *Trans) Select(cols ...string) *SelectBuilder {
+	return newSelect(t.driver, t.sqlY.argFmt, t.QueryCtx, t.GetCtx, cols)
+}
+
+// InsertInto build insert statement executed in transaction
+func (t *Trans) InsertInto(table string) *InsertBuilder {
+	return &InsertBuilder{driver: t.driver, argFmt: t.sqlY.argFmt, execMany: t.InsertManyCtx, table: table}
+}
+
+// UpdateTable build update statement executed in transaction
+func (t *Trans) UpdateTable(table string) *UpdateBuilder {
+	return &UpdateBuilder{driver: t.driver, argFmt: t.sqlY.argFmt, exec: t.ExecCtx, table: table}
+}
+
+// DeleteFrom build delete statement executed in transaction
+func (t *Trans) DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{driver: t.driver, argFmt: t.sqlY.argFmt, exec: t.ExecCtx, table: table}
+}
+
+// Paginate query rows of page to dest (slice pointer), by offset or keyset
+func (t *Trans) Paginate(ctx context.Context, dest interface{}, query string, opts PageOpts,
+	args ...interface{}) (*Page, error) {
+	return paginate(ctx, t.tx, t.driver, t.sqlY.argFmt, dest, query, opts, args...)
+}
+
+// StmtCacheStats statistics of prepared statement cache of transaction
+func (t *Trans) StmtCacheStats() StmtCacheStats {
+	if t.stmts == nil {
+		return StmtCacheStats{}
+	}
+	return t.stmts.statistics()
+}
+
+// CopyFrom bulk load rows into table with COPY FROM of postgresql, returns the number of rows copied,
+// source is [][]interface{} in order of columns, slice of struct (struct pointer, map[string]interface{}) or RowSource
+func (t *Trans) CopyFrom(ctx context.Context, table string, columns []string, source interface{}) (int64, error) {
+	if t.driver != driverPostgresql {
+		return 0, ErrNotSupportForThisDriver
+	}
+	return copyFrom(ctx, t.tx, table, columns, source)
+}
+
+// LoadData bulk load rows into table with LOAD DATA LOCAL INFILE of mysql
+func (t *Trans) LoadData(ctx context.Context, table string, columns []string, source interface{}) (*Affected, error) {
+	return loadData(ctx, t.tx, t.driver, table, columns, source)
+}
+
+// Lock acquire the lock of key which is released at the end of transaction,
+// it waits until the lock is acquired, postgresql (pg_advisory_xact_lock) only
+func (t *Trans) Lock(ctx context.Context, key string) error {
+	_, err := acquireXactLock(ctx, t.tx, t.driver, t.sqlY.argFmt, key, true)
+	return err
+}
+
+// TryLock acquire the lock of key which is released at the end of transaction,
+// false if the lock is held by others, postgresql (pg_try_advisory_xact_lock) only
+func (t *Trans) TryLock(ctx context.Context, key string) (bool, error) {
+	return acquireXactLock(ctx, t.tx, t.driver, t.sqlY.argFmt, key, false)
+}
+
+// GetForUpdate query rows with locking clause (FOR UPDATE, FOR SHARE, NOWAIT, SKIP LOCKED) of opts,
+// one row if dest is struct pointer, or all rows if dest is slice pointer, postgresql and mysql 8 only
+func (t *Trans) GetForUpdate(ctx context.Context, dest interface{}, query string, opts RowLockOpts,
+	args ...interface{}) error {
+	query, err := forUpdateFmt(t.driver, query, opts)
+	if err != nil {
+		return err
+	}
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice {
+		return t.QueryCtx(ctx, dest, query, args...)
+	}
+	return t.GetCtx(ctx, dest, query, args...)
+}
+
+// ClaimBatch claim up to n rows of table matching cond in order of primary key, rows locked by others are skipped,
+// the claimed rows are updated with mark (map[string]interface{} or struct) and queried to dest (slice of struct),
+// postgresql and mysql 8 only
+func (t *Trans) ClaimBatch(ctx context.Context, n int64, dest interface{}, table string, cond Cond,
+	mark interface{}) error {
+	return claimBatch(ctx, t.tx, t.driver, t.sqlY.argFmt, n, dest, table, cond, mark)
+}
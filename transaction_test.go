@@ -7,9 +7,9 @@ import (
 )
 
 func TestSqlY_Transaction(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	res, err := db.Transaction(func(tx *Trans) (i interface{}, e error) {
 		ctx := context.TODO()
@@ -30,13 +30,13 @@ func TestSqlY_Transaction(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(res)
+	fmt.Println(res)
 }
 
 func TestSqlY_Transaction2(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	res, err := db.Transaction(func(tx *Trans) (i interface{}, e error) {
 		ctx := context.TODO()
@@ -57,13 +57,13 @@ func TestSqlY_Transaction2(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(res)
+	fmt.Println(res)
 }
 
 func TestSqlY_Transaction3(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	res, err := db.Transaction(func(tx *Trans) (i interface{}, e error) {
 		ctx := context.TODO()
@@ -102,13 +102,13 @@ func TestSqlY_Transaction3(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(res)
+	fmt.Println(res)
 }
 
 func TestSqlY_Transaction4(t *testing.T) {
-	db, err := New(opt)
+	db, err := New(testOption(t))
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	res, err := db.Transaction(func(tx *Trans) (i interface{}, e error) {
 		ctx := context.TODO()
@@ -130,5 +130,5 @@ func TestSqlY_Transaction4(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Sprintln(res)
+	fmt.Println(res)
 }
@@ -124,6 +124,45 @@ func (ns *NullInt64) UnmarshalJSON(b []byte) error {
 	return err
 }
 
+// NullUint64 nullable uint64, such as Nullable(UInt64) of clickhouse, which overflows NullInt64
+type NullUint64 struct {
+	Uint64 uint64
+	Valid  bool
+}
+
+// Scan implements the Scanner interface for NullUint64
+func (ns *NullUint64) Scan(val interface{}) error {
+	var s sql.NullString
+	if err := s.Scan(val); err != nil {
+		return err
+	}
+	if !s.Valid {
+		*ns = NullUint64{}
+		return nil
+	}
+	u, err := strconv.ParseUint(s.String, 10, 64)
+	if err != nil {
+		return err
+	}
+	*ns = NullUint64{Uint64: u, Valid: true}
+	return nil
+}
+
+// MarshalJSON for NullUint64
+func (ns *NullUint64) MarshalJSON() ([]byte, error) {
+	if !ns.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ns.Uint64)
+}
+
+// UnmarshalJSON for NullUint64
+func (ns *NullUint64) UnmarshalJSON(b []byte) error {
+	err := json.Unmarshal(b, &ns.Uint64)
+	ns.Valid = err == nil
+	return err
+}
+
 // NullInt32 is an alias for sql.NullInt32
 type NullInt32 sql.NullInt32
 
@@ -193,6 +232,9 @@ func (b *Boolean) Scan(val interface{}) error {
 	return nil
 }
 
+// Tuple tuple of values, it's formatted as tuple literal (a, b, ...) for clickhouse
+type Tuple []interface{}
+
 // array types
 // origin from github.com/lib/pq array
 
@@ -807,39 +849,104 @@ func (a StringArray) Value() (driver.Value, error) {
 	return "{}", nil
 }
 
+// clickhouse types(case sensitive) to the general ones
+var clickhouseTypes = map[string]string{
+	"Int8":        "TINYINT",
+	"UInt8":       "SMALLINT",
+	"Int16":       "SMALLINT",
+	"UInt16":      "INT",
+	"Int32":       "INT",
+	"UInt32":      "BIGINT",
+	"Int64":       "BIGINT",
+	"UInt64":      "UINT64",
+	"Float32":     "FLOAT",
+	"Float64":     "DOUBLE",
+	"Decimal":     "DECIMAL",
+	"String":      "VARCHAR",
+	"FixedString": "CHAR",
+	"Enum8":       "VARCHAR",
+	"Enum16":      "VARCHAR",
+	"Date":        "DATE",
+	"Date32":      "DATE",
+	"DateTime":    "DATETIME",
+	"DateTime64":  "DATETIME",
+	"Bool":        "BOOLEAN",
+}
+
+// databaseTypeName upper case type name of column without length and wrapper,
+// sqlite returns the declared type, such as VARCHAR(32),
+// clickhouse wraps the type, such as LowCardinality(Nullable(String)),
+// the column is nullable if it's wrapped by Nullable
+func databaseTypeName(ct *sql.ColumnType) (string, bool) {
+	name := strings.TrimSpace(ct.DatabaseTypeName())
+	nullAble, _ := ct.Nullable()
+	for {
+		if strings.HasPrefix(name, "LowCardinality(") && strings.HasSuffix(name, ")") {
+			name = name[len("LowCardinality(") : len(name)-1]
+		} else if strings.HasPrefix(name, "Nullable(") && strings.HasSuffix(name, ")") {
+			name = name[len("Nullable(") : len(name)-1]
+			nullAble = true
+		} else {
+			break
+		}
+	}
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = name[:i]
+	}
+	if t, ok := clickhouseTypes[name]; ok {
+		return t, nullAble
+	}
+	return strings.ToUpper(strings.TrimSpace(name)), nullAble
+}
+
 // ColumnsType Working with Unknown Columns
-// mysql only
+// mysql, sqlite, sql server and clickhouse
 func parseColumnsType(colsType []*sql.ColumnType) []interface{} {
 	var cTypes []interface{}
 
 	for _, ct := range colsType {
-		nullAble, _ := ct.Nullable()
-		switch strings.ToUpper(ct.DatabaseTypeName()) {
+		typeName, nullAble := databaseTypeName(ct)
+		switch typeName {
 		case "MEDIUMINT", "INT", "INTEGER", "BIGINT":
 			if nullAble {
 				cTypes = append(cTypes, new(NullInt64))
 			} else {
 				cTypes = append(cTypes, new(int64))
 			}
+		case "UINT64":
+			if nullAble {
+				cTypes = append(cTypes, new(NullUint64))
+			} else {
+				cTypes = append(cTypes, new(uint64))
+			}
 		case "SMALLINT", "TINYINT":
 			if nullAble {
 				cTypes = append(cTypes, new(NullInt32))
 			} else {
 				cTypes = append(cTypes, new(int32))
 			}
-		case "FLOAT", "DOUBLE", "DECIMAL":
+		case "FLOAT", "DOUBLE", "DECIMAL", "REAL", "NUMERIC", "MONEY", "SMALLMONEY":
 			if nullAble {
 				cTypes = append(cTypes, new(NullFloat64))
 			} else {
 				cTypes = append(cTypes, new(float64))
 			}
-		case "DATE", "TIME", "YEAR", "DATETIME", "TIMESTAMP":
+		case "DATE", "TIME", "YEAR", "DATETIME", "TIMESTAMP", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET":
 			if nullAble {
 				cTypes = append(cTypes, new(NullTime))
 			} else {
 				cTypes = append(cTypes, new(time.Time))
 			}
-		case "CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT":
+		case "BOOL", "BOOLEAN", "BIT":
+			// BIT(n) of mysql is scanned as bytes, only BIT of sql server is boolean
+			if typeName == "BIT" && ct.ScanType().Kind() != reflect.Bool {
+				cTypes = append(cTypes, new(sql.RawBytes))
+			} else if nullAble {
+				cTypes = append(cTypes, new(NullBool))
+			} else {
+				cTypes = append(cTypes, new(bool))
+			}
+		case "CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT", "CLOB", "NCHAR", "NVARCHAR", "NTEXT":
 			if nullAble {
 				cTypes = append(cTypes, new(NullString))
 			} else {
@@ -0,0 +1,31 @@
+package sqly
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestMysql_QueryMapBit(t *testing.T) {
+	db := newFakeDb(t, fakeMysql, "fake_mysql", driverMysql, func(query string, args []driver.NamedValue) (*fakeResult, error) {
+		return &fakeResult{
+			columns: []fakeColumn{
+				{name: "flags", typeName: "BIT", scanType: reflect.TypeOf(sql.RawBytes{})},
+				{name: "is_valid", typeName: "BIT", nullable: true, scanType: reflect.TypeOf(sql.RawBytes{})},
+			},
+			rows: [][]driver.Value{{[]byte{0x05}, nil}},
+		}, nil
+	})
+	res := make(map[string]interface{})
+	if err := db.GetCtx(context.TODO(), &res, "SELECT `flags`, `is_valid` FROM `account`"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := res["flags"].(*sql.RawBytes); !ok || len(*v) != 1 || (*v)[0] != 0x05 {
+		t.Errorf("flags %v", res["flags"])
+	}
+	if v, ok := res["is_valid"].(*sql.RawBytes); !ok || *v != nil {
+		t.Errorf("is_valid %v", res["is_valid"])
+	}
+}